@@ -1,15 +1,27 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/rhodeon/expression-parser/pkg/solver"
 	"log"
 	"os"
+	"strings"
+	"unicode/utf8"
 )
 
 func main() {
 	expr := flag.String("expr", "", "expression to solve")
+	precision := flag.Int("precision", 0, "significant digits to compute with exactly in decimal (0 for float64)")
+	rational := flag.String("rational", "", "compute with exact fractions, written as a fraction, mixed or decimal")
+	approximate := flag.Bool("approximate", false, "approximate results which aren't rational in rational mode instead of failing")
+	integer := flag.Bool("integer", false, "compute with integers of any size and allow the bitwise operators")
+	complexMode := flag.Bool("complex", false, "compute with complex numbers, with i as the imaginary unit")
+	units := flag.Bool("units", false, "compute with physical quantities, and convert them with to or in")
+	currency := flag.Bool("currency", false, "compute with amounts of money such as $12.50 or 15 EUR (requires --rates)")
+	rates := flag.String("rates", "", "JSON or CSV file of exchange rates for converting currencies, required by and implying --currency")
+	base := flag.Int("base", 10, "base from 2 to 36 to write integer results in")
 	flag.Parse()
 
 	if *expr == "" {
@@ -17,11 +29,153 @@ func main() {
 		os.Exit(0)
 	}
 
-	_, err := solver.Validate(*expr)
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if modes := chosenModes(set); len(modes) > 1 {
+		fmt.Fprintf(os.Stderr, "Exparse: only one of %s may be given\n", strings.Join(modes, ", "))
+		os.Exit(2)
+	}
+	if set["approximate"] && !set["rational"] {
+		fmt.Fprintln(os.Stderr, "Exparse: --approximate can only be used with --rational")
+		os.Exit(2)
+	}
+
+	var opts []solver.Option
+	if set["precision"] {
+		opts = append(opts, solver.WithPrecision(*precision))
+	}
+	if set["rational"] {
+		format, ok := rationalFormats[*rational]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Exparse: unknown rational format %q, expected fraction, mixed or decimal\n", *rational)
+			os.Exit(2)
+		}
+
+		inexact := solver.RejectInexact
+		if *approximate {
+			inexact = solver.ApproximateInexact
+		}
+		opts = append(opts, solver.WithRational(format, inexact))
+	}
+	if *integer {
+		opts = append(opts, solver.WithInteger())
+	}
+	if *complexMode {
+		opts = append(opts, solver.WithComplex())
+	}
+	if *units {
+		opts = append(opts, solver.WithUnits())
+	}
+	if *currency || *rates != "" {
+		// without rates no currency could be converted, so they are required rather than silently empty
+		if *rates == "" {
+			fmt.Fprintln(os.Stderr, "Exparse: --currency needs a file of exchange rates given with --rates")
+			os.Exit(2)
+		}
+
+		provider, err := solver.LoadRates(*rates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Exparse: %v\n", err)
+			os.Exit(2)
+		}
+		opts = append(opts, solver.WithCurrency(provider))
+	}
+
+	result, err := solver.Eval(*expr, opts...)
+	if err != nil {
+		var syntaxErr *solver.SyntaxError
+		var evalErr *solver.EvalError
+
+		switch {
+		case errors.As(err, &syntaxErr):
+			printError(*expr, err, syntaxErr.Offset, syntaxErr.Length, syntaxErr.Column)
+		case errors.As(err, &evalErr):
+			printError(*expr, err, evalErr.Offset, evalErr.Length, evalErr.Column)
+		default:
+			log.Fatalln(err)
+		}
+		os.Exit(1)
+	}
+
+	text, err := result.Text(*base)
 	if err != nil {
-		log.Fatalln(err)
+		fmt.Fprintf(os.Stderr, "Exparse: %s = %s cannot be written in base %d: %v\n", *expr, result, *base, err)
+		os.Exit(1)
+	}
+
+	for _, warning := range result.Warnings() {
+		fmt.Fprintf(os.Stderr, "Exparse: warning: %s\n", warning)
+	}
+	if rows, ok := result.Rows(); ok {
+		fmt.Printf("Exparse: %s =\n%s", *expr, grid(rows))
+		return
+	}
+	fmt.Printf("Exparse: %s = %s\n", *expr, text)
+}
+
+// grid writes the rows of a matrix on lines of their own, indented and with the numbers of each column aligned right.
+func grid(rows [][]string) string {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for j, cell := range row {
+			if width := utf8.RuneCountInString(cell); width > widths[j] {
+				widths[j] = width
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		for j, cell := range row {
+			b.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)+2))
+			b.WriteString(cell)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// modeFlags are the flags choosing how numbers are computed, of which only one may be given.
+// --rates implies --currency, so either or both choose the currency mode.
+var modeFlags = [][]string{
+	{"precision"},
+	{"rational"},
+	{"integer"},
+	{"complex"},
+	{"units"},
+	{"currency", "rates"},
+}
+
+// chosenModes returns the mode flags which were set, written as they are on the command line.
+func chosenModes(set map[string]bool) []string {
+	var modes []string
+	for _, names := range modeFlags {
+		for _, name := range names {
+			if set[name] {
+				modes = append(modes, "--"+name)
+				break
+			}
+		}
+	}
+	return modes
+}
+
+// rationalFormats are the values of the rational flag.
+var rationalFormats = map[string]solver.RationalFormat{
+	"fraction": solver.Fraction,
+	"mixed":    solver.MixedNumber,
+	"decimal":  solver.DecimalExpansion,
+}
+
+// printError prints the error followed by the expression with a caret under the offending text.
+func printError(expr string, err error, offset, length, column int) {
+	width := utf8.RuneCountInString(expr[offset : offset+length])
+	if width == 0 {
+		// point just past the end of the expression
+		width = 1
 	}
 
-	result := solver.Solve(*expr)
-	fmt.Printf("Exparse: %s = %s\n", *expr, result)
+	fmt.Fprintf(os.Stderr, "Exparse: %v\n", err)
+	fmt.Fprintf(os.Stderr, "  %s\n", expr)
+	fmt.Fprintf(os.Stderr, "  %s%s\n", strings.Repeat(" ", column-1), strings.Repeat("^", width))
 }
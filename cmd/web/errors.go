@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"github.com/rhodeon/expression-parser/pkg/solver"
 	"github.com/rhodeon/prettylog"
 	"net/http"
 )
@@ -9,3 +11,32 @@ func serverError(w http.ResponseWriter, err error) {
 	prettylog.Error(err)
 	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 }
+
+// highlightError locates the text of expr an error refers to.
+// nil is returned if err doesn't carry a location.
+func highlightError(expr string, err error) *Highlight {
+	var syntaxErr *solver.SyntaxError
+	var evalErr *solver.EvalError
+	var offset, length int
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset, length = syntaxErr.Offset, syntaxErr.Length
+	case errors.As(err, &evalErr):
+		offset, length = evalErr.Offset, evalErr.Length
+	default:
+		return nil
+	}
+
+	highlight := &Highlight{
+		Before: expr[:offset],
+		Text:   expr[offset : offset+length],
+		After:  expr[offset+length:],
+	}
+
+	if highlight.Text == "" {
+		// mark the position just past the end of the expression
+		highlight.Text = " "
+	}
+	return highlight
+}
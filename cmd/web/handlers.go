@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"github.com/rhodeon/expression-parser/pkg/solver"
 	"github.com/rhodeon/prettylog"
 	"net/http"
+	"strconv"
 )
 
 func serveStaticFiles(w http.ResponseWriter, r *http.Request) {
@@ -43,24 +46,65 @@ func calculateResult(w http.ResponseWriter, r *http.Request) {
 	}
 
 	form := r.PostForm
-	expr := form.Get("expr")
-	prettylog.InfoF("Expression: %s", expr)
+	data := TemplateData{Expr: form.Get("expr"), Precision: form.Get("precision"), Base: form.Get("base")}
+	prettylog.InfoF("Expression: %s", data.Expr)
 
-	_, err = solver.Validate(expr)
+	opts, err := solverOptions(data)
 	if err != nil {
-		prettylog.ErrorLn(err)
-		renderTemplate(w, homePage, TemplateData{Expr: expr, Error: err.Error()})
+		data.Error = err.Error()
+		renderTemplate(w, homePage, data)
 		return
 	}
 
-	result := solver.Solve(expr)
+	result, err := solver.Eval(data.Expr, opts...)
+	if err != nil {
+		prettylog.ErrorLn(err)
+		data.Error, data.Highlight = err.Error(), highlightError(data.Expr, err)
+		renderTemplate(w, homePage, data)
+		return
+	}
 	prettylog.InfoF("Result: %s", result)
 
+	data.Result, err = resultText(result, data)
 	if err != nil {
-		prettylog.ErrorLn(err)
-		renderTemplate(w, homePage, TemplateData{Expr: expr, Error: err.Error()})
+		data.Error = err.Error()
+		renderTemplate(w, homePage, data)
 		return
 	}
+	// matrices are shown as tables rather than as lists of lists
+	data.Rows, _ = result.Rows()
+	renderTemplate(w, resultPage, data)
+}
+
+// solverOptions converts the settings submitted with an expression to options for solving it.
+func solverOptions(data TemplateData) ([]solver.Option, error) {
+	var opts []solver.Option
+
+	if data.Precision != "" {
+		digits, err := strconv.Atoi(data.Precision)
+		if err != nil || digits < 0 {
+			return nil, errors.New("precision must be a whole number of digits")
+		}
+		opts = append(opts, solver.WithPrecision(digits))
+	}
+
+	return opts, nil
+}
+
+// resultText writes a result in the base submitted with its expression, or in base 10 if there is none.
+func resultText(result solver.Result, data TemplateData) (string, error) {
+	if data.Base == "" {
+		return result.String(), nil
+	}
 
-	renderTemplate(w, resultPage, TemplateData{Expr: expr, Result: result})
+	base, err := strconv.Atoi(data.Base)
+	if err != nil {
+		return "", solver.ErrBase
+	}
+
+	text, err := result.Text(base)
+	if errors.Is(err, solver.ErrNotInteger) {
+		return "", fmt.Errorf("%s is not an integer and can only be shown in base 10", result)
+	}
+	return text, err
 }
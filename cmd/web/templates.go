@@ -7,9 +7,20 @@ import (
 )
 
 type TemplateData struct {
-	Expr   string
-	Result string
-	Error  string
+	Expr      string
+	Precision string
+	Base      string
+	Result    string
+	Rows      [][]string
+	Error     string
+	Highlight *Highlight
+}
+
+// Highlight splits an expression around the text an error refers to.
+type Highlight struct {
+	Before string
+	Text   string
+	After  string
 }
 
 func renderTemplate(w http.ResponseWriter, files []string, td TemplateData) {
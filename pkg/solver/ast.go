@@ -0,0 +1,286 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Node is an element of the abstract syntax tree produced by Parse.
+type Node interface {
+	// Pos returns the byte offset of the first character belonging to the node.
+	Pos() int
+	// End returns the byte offset just past the last character belonging to the node.
+	End() int
+	// String returns a fully parenthesized representation of the node.
+	String() string
+}
+
+// span records the location of a node in the original expression.
+type span struct {
+	start, end int
+}
+
+func (s span) Pos() int { return s.start }
+func (s span) End() int { return s.end }
+
+// NumberNode is a numeric literal.
+// Value is the closest float64 to the literal, which is infinite for literals beyond the range of float64
+// parsed for number systems which can represent them.
+type NumberNode struct {
+	span
+	Value float64
+}
+
+func (n *NumberNode) String() string {
+	return strconv.FormatFloat(n.Value, 'f', -1, 64)
+}
+
+// BoolNode is one of the boolean literals true and false.
+type BoolNode struct {
+	span
+	Value bool
+}
+
+func (n *BoolNode) String() string {
+	return strconv.FormatBool(n.Value)
+}
+
+// StringNode is a string literal, such as "km". Value is the text between the quotes with its escapes resolved.
+type StringNode struct {
+	span
+	Value string
+}
+
+func (n *StringNode) String() string {
+	return strconv.Quote(n.Value)
+}
+
+// IdentNode is a reference to a named value, such as a constant.
+type IdentNode struct {
+	span
+	Name string
+}
+
+func (n *IdentNode) String() string {
+	return n.Name
+}
+
+// UnaryNode is a prefix operator applied to a single operand.
+type UnaryNode struct {
+	span
+	Op      string
+	Operand Node
+}
+
+func (n *UnaryNode) String() string {
+	return fmt.Sprintf("(%s%s)", n.Op, n.Operand)
+}
+
+// PercentNode is an operand followed by the percent sign, such as 10%, which is a hundredth of the operand.
+// As the right operand of +, -, * or /, it is a percentage of the left operand instead, so that 200 + 10% is 220.
+type PercentNode struct {
+	span
+	Operand Node
+}
+
+func (n *PercentNode) String() string {
+	return fmt.Sprintf("(%s%s)", n.Operand, percent)
+}
+
+// FactorialNode is an operand followed by the factorial sign, such as 5!, which is the factorial of the operand.
+type FactorialNode struct {
+	span
+	Operand Node
+}
+
+func (n *FactorialNode) String() string {
+	return fmt.Sprintf("(%s%s)", n.Operand, factorialSign)
+}
+
+// BinaryNode is an infix operator applied to two operands.
+type BinaryNode struct {
+	span
+	Op    string
+	Left  Node
+	Right Node
+	// OpPos and OpEnd are the byte offsets of the operator's text,
+	// which are equal if it has none, as an implicit multiplication doesn't.
+	OpPos, OpEnd int
+}
+
+func (n *BinaryNode) String() string {
+	return fmt.Sprintf("(%s %s %s)", n.Left, n.Op, n.Right)
+}
+
+// GroupNode is an expression enclosed in parentheses.
+type GroupNode struct {
+	span
+	Expr Node
+}
+
+func (n *GroupNode) String() string {
+	return fmt.Sprintf("(%s)", n.Expr)
+}
+
+// ConditionalNode is an expression choosing between two others depending on a boolean condition,
+// written "cond ? then : else" or "if(cond, then, else)". Only the chosen branch is evaluated.
+type ConditionalNode struct {
+	span
+	Cond Node
+	Then Node
+	Else Node
+}
+
+func (n *ConditionalNode) String() string {
+	return fmt.Sprintf("(%s %s %s %s %s)", n.Cond, question, n.Then, colon, n.Else)
+}
+
+// ListNode is a list literal, such as [1, 2, 3].
+type ListNode struct {
+	span
+	Elements []Node
+}
+
+func (n *ListNode) String() string {
+	elements := make([]string, len(n.Elements))
+	for i, element := range n.Elements {
+		elements[i] = element.String()
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+// IndexNode is an element of a list selected by its index, such as xs[0].
+// Indices start at 0, and negative ones count from the end, so xs[-1] is the last element.
+type IndexNode struct {
+	span
+	Operand Node
+	Index   Node
+}
+
+func (n *IndexNode) String() string {
+	return fmt.Sprintf("%s[%s]", n.Operand, n.Index)
+}
+
+// CallNode is a call of a named function with a list of arguments.
+type CallNode struct {
+	span
+	Name string
+	Args []Node
+}
+
+func (n *CallNode) String() string {
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s(%s)", n.Name, strings.Join(args, ", "))
+}
+
+// AssignNode is a statement assigning the value of an expression to a name.
+type AssignNode struct {
+	span
+	Name  string
+	Value Node
+}
+
+func (n *AssignNode) String() string {
+	return fmt.Sprintf("%s = %s", n.Name, n.Value)
+}
+
+// FuncDefNode is a statement defining a function of named parameters.
+type FuncDefNode struct {
+	span
+	Name   string
+	Params []string
+	Body   Node
+}
+
+func (n *FuncDefNode) String() string {
+	return fmt.Sprintf("%s(%s) = %s", n.Name, strings.Join(n.Params, ", "), n.Body)
+}
+
+// ProgramNode is a list of statements evaluated in order.
+// Its value is the value of the last statement.
+type ProgramNode struct {
+	span
+	Statements []Node
+}
+
+func (n *ProgramNode) String() string {
+	statements := make([]string, len(n.Statements))
+	for i, statement := range n.Statements {
+		statements[i] = statement.String()
+	}
+	return strings.Join(statements, "; ")
+}
+
+// walk calls visit for a node and each of its descendants, parents first.
+func walk(node Node, visit func(Node)) {
+	visit(node)
+
+	switch node := node.(type) {
+	case *UnaryNode:
+		walk(node.Operand, visit)
+	case *PercentNode:
+		walk(node.Operand, visit)
+	case *FactorialNode:
+		walk(node.Operand, visit)
+	case *BinaryNode:
+		walk(node.Left, visit)
+		walk(node.Right, visit)
+	case *GroupNode:
+		walk(node.Expr, visit)
+	case *ConditionalNode:
+		walk(node.Cond, visit)
+		walk(node.Then, visit)
+		walk(node.Else, visit)
+	case *ListNode:
+		for _, element := range node.Elements {
+			walk(element, visit)
+		}
+	case *IndexNode:
+		walk(node.Operand, visit)
+		walk(node.Index, visit)
+	case *CallNode:
+		for _, arg := range node.Args {
+			walk(arg, visit)
+		}
+	case *AssignNode:
+		walk(node.Value, visit)
+	case *FuncDefNode:
+		walk(node.Body, visit)
+	case *ProgramNode:
+		for _, statement := range node.Statements {
+			walk(statement, visit)
+		}
+	}
+}
+
+// operands returns the operator and operands a binary node is evaluated with. Percentages on the right of
+// +, -, * and / and on the left of "of" are replaced with their operands and one of the operators of percentages,
+// so that 200 + 10% is evaluated as 200 +% 10.
+func operands(node *BinaryNode) (string, Node, Node) {
+	if node.Op == percentOf {
+		return percentOf, node.Left.(*PercentNode).Operand, node.Right
+	}
+
+	if pct, ok := node.Right.(*PercentNode); ok && percentOperators[node.Op] != "" {
+		return percentOperators[node.Op], node.Left, pct.Operand
+	}
+	return node.Op, node.Left, node.Right
+}
+
+// isPercentage reports whether the value of a node is a number of percent, which is the case
+// when its last statement is of the form "a as % of b".
+func isPercentage(node Node) bool {
+	switch node := node.(type) {
+	case *ProgramNode:
+		return isPercentage(node.Statements[len(node.Statements)-1])
+	case *GroupNode:
+		return isPercentage(node.Expr)
+	case *BinaryNode:
+		return node.Op == asPercentOf
+	}
+	return false
+}
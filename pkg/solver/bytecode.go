@@ -0,0 +1,391 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// opcode identifies the operation performed by an instruction of the virtual machine.
+type opcode byte
+
+// operations of the virtual machine, which work on a stack of values
+const (
+	opConst     opcode = iota // push constants[arg]
+	opBool                    // push the boolean arg, which is 1 for true and 0 for false
+	opValue                   // push values[arg], a constant which isn't a number or a boolean
+	opList                    // replace the top arg values of the stack with the list of them
+	opIndex                   // replace a list and an index on top of the stack with the element at the index
+	opLoad                    // push the value of names[arg]
+	opParam                   // push argument arg of the function being called
+	opNegate                  // replace the top of the stack with its negation
+	opNot                     // replace the boolean on top of the stack with its negation
+	opPercent                 // replace the top of the stack with its hundredth
+	opFactorial               // replace the top of the stack with its factorial
+
+	// binary operations replace the top two values of the stack with the result of the operator
+	opAdd
+	opSubtract
+	opMultiply
+	opMultiplyElements
+	opDivide
+	opFloorDivide
+	opModulus
+	opPower
+	opAddPercent
+	opSubtractPercent
+	opMultiplyPercent
+	opDividePercent
+	opPercentOf
+	opAsPercentOf
+	opLess
+	opLessOrEqual
+	opEqual
+	opNotEqual
+	opGreaterOrEqual
+	opGreater
+	opRange
+
+	opJump        // continue at instruction arg
+	opJumpUnless  // pop the boolean on top of the stack and continue at instruction arg if it is false
+	opJumpIfFalse // continue at instruction arg if the boolean on top of the stack is false, or pop it otherwise
+	opJumpIfTrue  // continue at instruction arg if the boolean on top of the stack is true, or pop it otherwise
+	opCheckBool   // fail unless the top of the stack is a boolean, for the right operands of && and ||
+
+	opCall   // replace the arguments of calls[arg] on top of the stack with the result of the call
+	opAssign // assign the top of the stack to names[arg], leaving it in place
+	opDefine // define funcs[arg] and push 0
+	opPop    // discard the top of the stack
+
+	// fail with the error of unsupportedOperator, for the operators of integer or units mode
+	// in functions defined in that mode but called outside of it
+	opModeOnly
+)
+
+// opNames are the mnemonics of the operations used when disassembling.
+var opNames = [...]string{
+	opConst:            "const",
+	opBool:             "bool",
+	opValue:            "value",
+	opList:             "list",
+	opIndex:            "index",
+	opLoad:             "load",
+	opParam:            "param",
+	opNegate:           "neg",
+	opNot:              "not",
+	opPercent:          "pct",
+	opFactorial:        "fact",
+	opAdd:              "add",
+	opSubtract:         "sub",
+	opMultiply:         "mul",
+	opMultiplyElements: "emul",
+	opDivide:           "div",
+	opFloorDivide:      "floordiv",
+	opModulus:          "mod",
+	opPower:            "pow",
+	opAddPercent:       "addpct",
+	opSubtractPercent:  "subpct",
+	opMultiplyPercent:  "mulpct",
+	opDividePercent:    "divpct",
+	opPercentOf:        "pctof",
+	opAsPercentOf:      "aspctof",
+	opLess:             "lt",
+	opLessOrEqual:      "le",
+	opEqual:            "eq",
+	opNotEqual:         "ne",
+	opGreaterOrEqual:   "ge",
+	opGreater:          "gt",
+	opRange:            "range",
+	opJump:             "jump",
+	opJumpUnless:       "popjumpf",
+	opJumpIfFalse:      "jumpf",
+	opJumpIfTrue:       "jumpt",
+	opCheckBool:        "isbool",
+	opCall:             "call",
+	opAssign:           "assign",
+	opDefine:           "define",
+	opPop:              "pop",
+	opModeOnly:         "modeonly",
+}
+
+// binaryOps maps binary operators to their operations, and binaryOperators maps them back.
+var (
+	binaryOps = map[string]opcode{
+		add:              opAdd,
+		subtract:         opSubtract,
+		multiply:         opMultiply,
+		multiplyElements: opMultiplyElements,
+		divide:           opDivide,
+		floorDivide:      opFloorDivide,
+		modulus:          opModulus,
+		power:            opPower,
+		addPercent:       opAddPercent,
+		subtractPercent:  opSubtractPercent,
+		multiplyPercent:  opMultiplyPercent,
+		dividePercent:    opDividePercent,
+		percentOf:        opPercentOf,
+		asPercentOf:      opAsPercentOf,
+		less:             opLess,
+		lessOrEqual:      opLessOrEqual,
+		equal:            opEqual,
+		notEqual:         opNotEqual,
+		greaterOrEqual:   opGreaterOrEqual,
+		greater:          opGreater,
+		listRange:        opRange,
+	}
+	binaryOperators = [...]string{
+		opAdd:              add,
+		opSubtract:         subtract,
+		opMultiply:         multiply,
+		opMultiplyElements: multiplyElements,
+		opDivide:           divide,
+		opFloorDivide:      floorDivide,
+		opModulus:          modulus,
+		opPower:            power,
+		opAddPercent:       addPercent,
+		opSubtractPercent:  subtractPercent,
+		opMultiplyPercent:  multiplyPercent,
+		opDividePercent:    dividePercent,
+		opPercentOf:        percentOf,
+		opAsPercentOf:      asPercentOf,
+		opLess:             less,
+		opLessOrEqual:      lessOrEqual,
+		opEqual:            equal,
+		opNotEqual:         notEqual,
+		opGreaterOrEqual:   greaterOrEqual,
+		opGreater:          greater,
+		opRange:            listRange,
+	}
+)
+
+// instruction is a single operation of the virtual machine.
+type instruction struct {
+	op  opcode
+	arg int
+	// node is the part of the tree the instruction was compiled from, which errors are located at.
+	node Node
+}
+
+// callSite describes a function call made by an opCall instruction.
+type callSite struct {
+	name string
+	args int
+}
+
+// chunk is an expression compiled to instructions for the virtual machine.
+type chunk struct {
+	code []instruction
+	// expr is the expression the chunk was compiled from.
+	expr      string
+	constants []float64
+	// values are the constants which aren't numbers, boxed once so that pushing them doesn't allocate.
+	values []Value
+	names  []string
+	calls  []callSite
+	funcs  []*userFunction
+	// params are the parameters of the function the chunk is the body of.
+	params []string
+	// refs are the references to names which must be defined before the chunk is run.
+	refs []*IdentNode
+}
+
+// compile lowers the abstract syntax tree parsed from expr to instructions for the virtual machine.
+func compile(expr string, node Node) *chunk {
+	c := &chunk{expr: expr, refs: undefinedNames(node, nil)}
+	c.compile(node)
+	return c
+}
+
+// compileFunction lowers the body of a function definition parsed from expr.
+// References to the parameters read the arguments of the call rather than names.
+func compileFunction(expr string, def *FuncDefNode) *chunk {
+	c := &chunk{expr: expr, params: def.Params}
+	c.compile(def.Body)
+	return c
+}
+
+// compile appends the instructions computing the value of a node.
+func (c *chunk) compile(node Node) {
+	switch node := node.(type) {
+	case *NumberNode:
+		c.emit(opConst, len(c.constants), node)
+		c.constants = append(c.constants, node.Value)
+
+	case *BoolNode:
+		arg := 0
+		if node.Value {
+			arg = 1
+		}
+		c.emit(opBool, arg, node)
+
+	case *StringNode:
+		c.emit(opValue, len(c.values), node)
+		c.values = append(c.values, String(node.Value))
+
+	case *IdentNode:
+		for i, param := range c.params {
+			if param == node.Name {
+				c.emit(opParam, i, node)
+				return
+			}
+		}
+		c.emit(opLoad, c.name(node.Name), node)
+
+	case *GroupNode:
+		c.compile(node.Expr)
+
+	case *UnaryNode:
+		c.compile(node.Operand)
+		switch _, ok := modeOperators[node.Op]; {
+		case ok:
+			c.emit(opModeOnly, 0, node)
+		case node.Op == not:
+			c.emit(opNot, 0, node)
+		default:
+			c.emit(opNegate, 0, node)
+		}
+
+	case *PercentNode:
+		c.compile(node.Operand)
+		c.emit(opPercent, 0, node)
+
+	case *FactorialNode:
+		c.compile(node.Operand)
+		c.emit(opFactorial, 0, node)
+
+	case *BinaryNode:
+		if node.Op == and || node.Op == or {
+			// the right operand is skipped if the left one decides the result on its own
+			jump := opJumpIfFalse
+			if node.Op == or {
+				jump = opJumpIfTrue
+			}
+
+			c.compile(node.Left)
+			skip := c.emit(jump, 0, node)
+			c.compile(node.Right)
+			c.emit(opCheckBool, 0, node)
+			c.code[skip].arg = len(c.code)
+			return
+		}
+
+		op, left, right := operands(node)
+		c.compile(left)
+		c.compile(right)
+		if _, ok := modeOperators[op]; ok {
+			c.emit(opModeOnly, 0, node)
+			return
+		}
+		c.emit(binaryOps[op], 0, node)
+
+	case *ConditionalNode:
+		c.compile(node.Cond)
+		otherwise := c.emit(opJumpUnless, 0, node.Cond)
+		c.compile(node.Then)
+		end := c.emit(opJump, 0, node)
+		c.code[otherwise].arg = len(c.code)
+		c.compile(node.Else)
+		c.code[end].arg = len(c.code)
+
+	case *ListNode:
+		for _, element := range node.Elements {
+			c.compile(element)
+		}
+		c.emit(opList, len(node.Elements), node)
+
+	case *IndexNode:
+		c.compile(node.Operand)
+		c.compile(node.Index)
+		c.emit(opIndex, 0, node)
+
+	case *CallNode:
+		for _, arg := range node.Args {
+			c.compile(arg)
+		}
+		c.emit(opCall, len(c.calls), node)
+		c.calls = append(c.calls, callSite{name: node.Name, args: len(node.Args)})
+
+	case *AssignNode:
+		c.compile(node.Value)
+		c.emit(opAssign, c.name(node.Name), node)
+
+	case *FuncDefNode:
+		c.emit(opDefine, len(c.funcs), node)
+		c.funcs = append(c.funcs, newUserFunction(node, c.expr))
+
+	case *ProgramNode:
+		for i, statement := range node.Statements {
+			if i > 0 {
+				// only the value of the last statement is kept
+				c.emit(opPop, 0, statement)
+			}
+			c.compile(statement)
+		}
+	}
+}
+
+// emit appends an instruction and returns its position, at which jumps are patched once their target is known.
+func (c *chunk) emit(op opcode, arg int, node Node) int {
+	c.code = append(c.code, instruction{op: op, arg: arg, node: node})
+	return len(c.code) - 1
+}
+
+// name returns the index of a name, adding it if it isn't already present.
+func (c *chunk) name(name string) int {
+	for i, existing := range c.names {
+		if existing == name {
+			return i
+		}
+	}
+
+	c.names = append(c.names, name)
+	return len(c.names) - 1
+}
+
+// undefined returns the references of the chunk to names which neither env nor the constants define.
+func (c *chunk) undefined(env *Env) []*IdentNode {
+	var undefined []*IdentNode
+	for _, ref := range c.refs {
+		if _, ok := env.lookup(ref.Name); !ok {
+			undefined = append(undefined, ref)
+		}
+	}
+	return undefined
+}
+
+// disassemble writes a listing of the instructions of the chunk,
+// followed by those of the functions it defines.
+func (c *chunk) disassemble(b *strings.Builder) {
+	for i, ins := range c.code {
+		var operand string
+		switch ins.op {
+		case opConst:
+			operand = strconv.FormatFloat(c.constants[ins.arg], 'f', -1, 64)
+		case opBool:
+			operand = strconv.FormatBool(ins.arg != 0)
+		case opValue:
+			operand = c.values[ins.arg].String()
+		case opList:
+			operand = strconv.Itoa(ins.arg)
+		case opJump, opJumpUnless, opJumpIfFalse, opJumpIfTrue:
+			operand = fmt.Sprintf("%04d", ins.arg)
+		case opLoad, opAssign:
+			operand = c.names[ins.arg]
+		case opParam:
+			operand = c.params[ins.arg]
+		case opCall:
+			operand = fmt.Sprintf("%s %d", c.calls[ins.arg].name, c.calls[ins.arg].args)
+		case opDefine:
+			operand = c.funcs[ins.arg].name
+		}
+
+		line := fmt.Sprintf("%04d  %-8s %s", i, opNames[ins.op], operand)
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteString("\n")
+	}
+
+	for _, fn := range c.funcs {
+		fmt.Fprintf(b, "\n%s(%s):\n", fn.name, strings.Join(fn.params, ", "))
+		fn.code.disassemble(b)
+	}
+}
@@ -0,0 +1,288 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+	"math/cmplx"
+)
+
+// imaginaryUnit is the name of the constant i, which is only defined in complex mode.
+const imaginaryUnit = "i"
+
+// complexFunctions are the library functions of a single argument with complex implementations.
+// The others only accept real arguments.
+var complexFunctions = map[string]func(complex128) complex128{
+	"sqrt":  cmplx.Sqrt,
+	"exp":   cmplx.Exp,
+	"ln":    cmplx.Log,
+	"log2":  func(z complex128) complex128 { return cmplx.Log(z) / math.Ln2 },
+	"log10": cmplx.Log10,
+	"sin":   cmplx.Sin,
+	"cos":   cmplx.Cos,
+	"tan":   cmplx.Tan,
+	"asin":  cmplx.Asin,
+	"acos":  cmplx.Acos,
+	"atan":  cmplx.Atan,
+	"sinh":  cmplx.Sinh,
+	"cosh":  cmplx.Cosh,
+	"tanh":  cmplx.Tanh,
+	"abs":   func(z complex128) complex128 { return complex(cmplx.Abs(z), 0) },
+	"arg":   func(z complex128) complex128 { return complex(cmplx.Phase(z), 0) },
+	"conj":  cmplx.Conj,
+	"re":    func(z complex128) complex128 { return complex(real(z), 0) },
+	"im":    func(z complex128) complex128 { return complex(imag(z), 0) },
+}
+
+// complexSystem evaluates expressions with complex128 numbers.
+type complexSystem struct{}
+
+func (s complexSystem) literal(text string) (interface{}, error) {
+	value, err := numberValue(text)
+	if err != nil {
+		return nil, err
+	}
+	return complex(value, 0), nil
+}
+
+func (s complexSystem) fromFloat(x float64) (interface{}, error) {
+	if math.IsNaN(x) {
+		return nil, ErrNotANumber
+	}
+	return complex(x, 0), nil
+}
+
+func (s complexSystem) constant(name string) (interface{}, error) {
+	if name == imaginaryUnit {
+		return 1i, nil
+	}
+	return complex(constants[name], 0), nil
+}
+
+func (s complexSystem) hasConstant(name string) bool {
+	return name == imaginaryUnit
+}
+
+func (s complexSystem) owns(x interface{}) bool {
+	_, ok := x.(complex128)
+	return ok
+}
+
+func (s complexSystem) unary(op string, x interface{}) (interface{}, error) {
+	if op != subtract {
+		return nil, unsupportedOperator(op)
+	}
+	return positiveZeros(-x.(complex128)), nil
+}
+
+// positiveZeros replaces negative zeros in either part of z with zeros, so that the negation of a real number
+// is still on the upper side of the branch cuts of functions such as sqrt, and sqrt(-1) is i rather than -i.
+func positiveZeros(z complex128) complex128 {
+	return complex(real(z)+0, imag(z)+0)
+}
+
+func (s complexSystem) operate(op string, left, right interface{}) (interface{}, error) {
+	l, r := left.(complex128), right.(complex128)
+
+	var result complex128
+	switch op {
+	case add:
+		result = l + r
+	case subtract:
+		result = l - r
+	case multiply:
+		result = l * r
+	case divide:
+		if r == 0 {
+			return nil, ErrDivisionByZero
+		}
+		result = l / r
+	case floorDivide, modulus:
+		if imag(l) != 0 || imag(r) != 0 {
+			return nil, ErrNotReal
+		}
+		value, err := operate(op, real(l), real(r))
+		if err != nil {
+			return nil, err
+		}
+		return complex(value, 0), nil
+	case power:
+		if err := checkPower(l == 0, real(r) < 0); err != nil {
+			return nil, err
+		}
+		result = complexPower(l, r)
+	default:
+		return nil, unsupportedOperator(op)
+	}
+
+	if err := checkComplex(result, ErrNotANumber, l, r); err != nil {
+		return nil, err
+	}
+	return positiveZeros(result), nil
+}
+
+// complexPower raises base to the power of exp. Integer powers are computed by repeated multiplication
+// and real powers of non-negative numbers with math.Pow, so that i^2 is exactly -1 and 2^0.5 is exactly sqrt(2).
+func complexPower(base, exp complex128) complex128 {
+	switch {
+	case imag(exp) != 0:
+		return cmplx.Pow(base, exp)
+	case imag(base) == 0 && real(base) >= 0:
+		return complex(math.Pow(real(base), real(exp)), 0)
+	case real(exp) != math.Trunc(real(exp)) || math.Abs(real(exp)) > 1<<62:
+		return cmplx.Pow(base, exp)
+	}
+
+	n := int64(real(exp))
+	if magnitude := math.Log2(cmplx.Abs(base)) * math.Abs(real(exp)); magnitude > 1024 {
+		// the intermediate products would overflow to infinities of no particular direction
+		if n < 0 {
+			return 0
+		}
+		return cmplx.Inf()
+	}
+
+	// exponentiation by squaring
+	result, square := complex128(1), base
+	for i := n; i != 0; i /= 2 {
+		if i%2 != 0 {
+			result *= square
+		}
+		square *= square
+	}
+
+	if n < 0 {
+		return 1 / result
+	}
+	return result
+}
+
+// compare only orders real numbers, while any numbers can be compared for equality.
+func (s complexSystem) compare(op string, left, right interface{}) (bool, error) {
+	l, r := left.(complex128), right.(complex128)
+	switch {
+	case op == equal || op == notEqual:
+		return (l == r) == (op == equal), nil
+	case imag(l) != 0 || imag(r) != 0:
+		return false, ErrNotReal
+	}
+	return compared(op, compareFloats(real(l), real(r))), nil
+}
+
+func (s complexSystem) call(name string, fn function, args []interface{}) (interface{}, error) {
+	zs := make([]complex128, len(args))
+	for i, arg := range args {
+		zs[i] = arg.(complex128)
+	}
+
+	var result complex128
+	switch f, ok := complexFunctions[name]; {
+	case name == "pow":
+		return s.operate(power, zs[0], zs[1])
+	case name == "log" && len(zs) == 2:
+		// the logarithm of a number in the base given by the second argument
+		if zs[0] == 0 || zs[1] == 0 || zs[1] == 1 {
+			return nil, ErrDomain
+		}
+		result = cmplx.Log(zs[0]) / cmplx.Log(zs[1])
+	case name == "log":
+		if zs[0] == 0 {
+			return nil, ErrDomain
+		}
+		result = cmplx.Log10(zs[0])
+	case ok:
+		if zs[0] == 0 && (name == "ln" || name == "log2" || name == "log10") {
+			// the logarithm of zero is an infinity of no particular direction
+			return nil, ErrDomain
+		}
+		result = f(zs[0])
+	default:
+		return s.callReal(fn, zs)
+	}
+
+	if err := checkComplex(result, ErrDomain, zs...); err != nil {
+		return nil, err
+	}
+	return positiveZeros(result), nil
+}
+
+// callReal applies a library function which is only defined for real numbers.
+func (s complexSystem) callReal(fn function, zs []complex128) (interface{}, error) {
+	floats := make([]float64, len(zs))
+	for i, z := range zs {
+		if imag(z) != 0 {
+			return nil, ErrNotReal
+		}
+		floats[i] = real(z)
+	}
+
+	result, err := fn.call(floats)
+	if err == nil {
+		err = checkFinite(result, ErrDomain, floats...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return complex(result, 0), nil
+}
+
+// checkComplex returns nanErr if either part of result is NaN, ErrOverflow if it is infinite
+// despite being computed from finite operands, and nil otherwise.
+func checkComplex(result complex128, nanErr error, operands ...complex128) error {
+	if cmplx.IsNaN(result) {
+		return nanErr
+	}
+
+	if cmplx.IsInf(result) {
+		for _, operand := range operands {
+			if cmplx.IsInf(operand) {
+				// infinity was part of the expression rather than the result of an overflow
+				return nil
+			}
+		}
+		return ErrOverflow
+	}
+
+	return nil
+}
+
+// float returns the real part of a real number, and NaN for a number with an imaginary part.
+func (s complexSystem) float(x interface{}) float64 {
+	z := x.(complex128)
+	if imag(z) != 0 {
+		return math.NaN()
+	}
+	return real(z)
+}
+
+func (s complexSystem) integer(x interface{}) (*big.Int, bool) {
+	z := x.(complex128)
+	if imag(z) != 0 {
+		return nil, false
+	}
+	return floatInteger(real(z))
+}
+
+// format writes a number such as 3+4i, leaving out parts which are zero and coefficients of 1.
+func (s complexSystem) format(x interface{}) string {
+	z := x.(complex128)
+	re, im := real(z), imag(z)
+	if im == 0 {
+		return formatFloat(re)
+	}
+
+	imaginary := imaginaryUnit
+	if coefficient := formatFloat(math.Abs(im)); coefficient != "1" {
+		imaginary = coefficient + imaginary
+	}
+
+	switch {
+	case re == 0 && im < 0:
+		return subtract + imaginary
+	case re == 0:
+		return imaginary
+	case im < 0:
+		return formatFloat(re) + subtract + imaginary
+	default:
+		return formatFloat(re) + add + imaginary
+	}
+}
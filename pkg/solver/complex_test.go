@@ -0,0 +1,126 @@
+package solver
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSolve_complex(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantResult string
+	}{
+		{"imaginary unit", "i", "i"},
+		{"complex literal", "3+4i", "3+4i"},
+		{"negative imaginary part", "3-4i", "3-4i"},
+		{"imaginary only", "-2i", "-2i"},
+		{"square root of minus one", "sqrt(-1)", "i"},
+		{"square root of negative", "sqrt(-4) + 1", "1+2i"},
+		{"square of i", "i^2", "-1"},
+		{"integer power", "(1+i)^4", "-4"},
+		{"negative power", "i^-1", "-i"},
+		{"product", "(3+4i)(3-4i)", "25"},
+		{"quotient", "(1+2i) / (3-4i)", "-0.2+0.4i"},
+		{"modulus", "abs(3+4i)", "5"},
+		{"argument", "arg(2i)", "1.5707963267948966"},
+		{"conjugate", "conj(3+4i)", "3-4i"},
+		{"real part", "re(3+4i)", "3"},
+		{"imaginary part", "im(3+4i)", "4"},
+		{"real arithmetic", "2^10 + 0.5", "1024.5"},
+		{"logarithm of negative", "ln(-1)", "3.141592653589793i"},
+		{"arcsine beyond one", "re(asin(2))", "1.5707963267948966"},
+		{"real-only function", "floor(2.5) + max(1, 2)", "4"},
+		{"program", "z = 1 + i; w = z * conj(z); w", "2"},
+		{"user-defined function", "norm(z) = sqrt(re(z)^2 + im(z)^2); norm(3-4i)", "5"},
+		{"variable shadows imaginary unit", "i = 2; i^2", "4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, WithComplex())
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_complexErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+	}{
+		{"division by zero", "(1+i) / (i - i)", []Option{WithComplex()}, ErrDivisionByZero},
+		{"negative power of zero", "0^-1", []Option{WithComplex()}, ErrDivisionByZero},
+		{"logarithm of zero", "ln(0)", []Option{WithComplex()}, ErrDomain},
+		{"floor division of complex", "(1+i) // 2", []Option{WithComplex()}, ErrNotReal},
+		{"real-only function", "floor(1+i)", []Option{WithComplex()}, ErrNotReal},
+		{"overflow", "(1+i)^10000", []Option{WithComplex()}, ErrOverflow},
+		{"undefined name", "j + 1", []Option{WithComplex()}, ErrUndefinedName},
+		{"square root of minus one in real mode", "sqrt(-1)", nil, ErrDomain},
+		{"imaginary unit in real mode", "3+4i", nil, ErrUndefinedName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResult_Complex(t *testing.T) {
+	result, err := Eval("(1+2i) * 2", WithComplex())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.Complex() != 2+4i || !math.IsNaN(result.Float()) {
+		t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", result.Complex(), result.Float(), 2+4i, math.NaN())
+	}
+
+	// real results of either mode are real numbers as well
+	for _, opts := range [][]Option{{WithComplex()}, nil} {
+		result, err := Eval("abs(-3) + 0.5", opts...)
+		if err != nil {
+			t.Fatalf("\nUnexpected error:\t%v", err)
+		}
+		if result.Complex() != 3.5 || result.Float() != 3.5 {
+			t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", result.Complex(), result.Float(), 3.5, 3.5)
+		}
+	}
+}
+
+func TestEvalWithEnv_complex(t *testing.T) {
+	env := NewEnv(map[string]float64{"r": 2})
+
+	result, err := EvalWithEnv("z = r * i; rotate(w) = w * i", env, WithComplex())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	// complex variables keep their value for later evaluations in complex mode
+	result, err = EvalWithEnv("rotate(z)", env, WithComplex())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "-2" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "-2")
+	}
+
+	// functions using the imaginary unit can't be called outside of it
+	_, err = EvalWithEnv("rotate(1)", env)
+	if !errors.Is(err, ErrUndefinedName) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrUndefinedName)
+	}
+}
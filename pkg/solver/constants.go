@@ -0,0 +1,20 @@
+package solver
+
+import "math"
+
+// constants are the named values available to every expression.
+var constants = map[string]float64{
+	"pi":  math.Pi,
+	"e":   math.E,
+	"tau": 2 * math.Pi,
+	"phi": math.Phi,
+	"inf": math.Inf(1),
+}
+
+// preciseConstants are the constants to 100 decimal places, for number systems more precise than float64.
+var preciseConstants = map[string]string{
+	"pi":  "3.1415926535897932384626433832795028841971693993751058209749445923078164062862089986280348253421170679",
+	"e":   "2.7182818284590452353602874713526624977572470936999595749669676277240766303535475945713821785251664274",
+	"tau": "6.2831853071795864769252867665590057683943387987502116419498891846156328125724179972560696506842341359",
+	"phi": "1.6180339887498948482045868343656381177203091798057628621354486227052604628189024497072072041893911374",
+}
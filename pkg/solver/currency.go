@@ -0,0 +1,276 @@
+package solver
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// currencyPrecision is the number of significant digits amounts of money are computed with,
+// which is that of the decimal128 format.
+const currencyPrecision = 34
+
+// currencyPlaces is the number of decimal places amounts of money are written with.
+const currencyPlaces = 2
+
+// currencySymbols map the symbols which can precede an amount, as in $12.50, to the codes of their currencies.
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+}
+
+// RateProvider supplies the exchange rates used to convert between currencies in evaluations with WithCurrency.
+// Currencies are identified by their ISO 4217 codes, such as USD or EUR.
+type RateProvider interface {
+	// Rate returns the amount of the currency to that one unit of the currency from is worth.
+	// Unknown currencies are reported with an error wrapping ErrUnknownCurrency.
+	Rate(from, to string) (float64, error)
+}
+
+// CurrencyError describes an operation combining amounts of money which isn't allowed,
+// such as adding dollars to euros without converting either. It wraps ErrCurrency so that it can be matched with errors.Is.
+type CurrencyError struct {
+	// Op is the operator or function applied.
+	Op string
+	// Left and Right are the currency codes of the operands, which are empty for plain numbers.
+	// Right is empty for functions which only accept plain numbers.
+	Left, Right string
+}
+
+func (e *CurrencyError) Error() string {
+	return fmt.Sprintf("%s for %s: %s and %s", ErrCurrency, e.Op, currencyName(e.Left), currencyName(e.Right))
+}
+
+func (e *CurrencyError) Unwrap() error {
+	return ErrCurrency
+}
+
+// currencyName returns the code of a currency as written in errors, which is "number" for plain numbers.
+func currencyName(code string) string {
+	if code == "" {
+		return "number"
+	}
+	return code
+}
+
+// isCurrencyCode reports whether a name has the form of an ISO 4217 code, which is three capital letters.
+func isCurrencyCode(name string) bool {
+	if len(name) != 3 {
+		return false
+	}
+	for _, char := range name {
+		if char < 'A' || char > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// money is a number of currency mode: an amount of a currency, or a plain number if the currency is empty.
+type money struct {
+	// amount is a decimalNumber.
+	amount   interface{}
+	currency string
+}
+
+// currencySystem evaluates expressions with amounts of money, which are decimal numbers with currencies,
+// converting between currencies with the rates of a RateProvider.
+type currencySystem struct {
+	decimal decimalSystem
+	rates   RateProvider
+}
+
+// wrap returns an amount of a currency, or the error computing the amount failed with.
+func (s currencySystem) wrap(amount interface{}, currency string, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return money{amount: amount, currency: currency}, nil
+}
+
+func (s currencySystem) literal(text string) (interface{}, error) {
+	amount, err := s.decimal.literal(text)
+	return s.wrap(amount, "", err)
+}
+
+func (s currencySystem) fromFloat(x float64) (interface{}, error) {
+	amount, err := s.decimal.fromFloat(x)
+	return s.wrap(amount, "", err)
+}
+
+// constant returns one unit of a currency, or the value of one of the named constants.
+func (s currencySystem) constant(name string) (interface{}, error) {
+	if isCurrencyCode(name) {
+		return money{amount: decimalNumber{coef: big.NewInt(1)}, currency: name}, nil
+	}
+
+	amount, err := s.decimal.constant(name)
+	return s.wrap(amount, "", err)
+}
+
+func (s currencySystem) hasConstant(name string) bool {
+	return isCurrencyCode(name)
+}
+
+func (s currencySystem) owns(x interface{}) bool {
+	_, ok := x.(money)
+	return ok
+}
+
+func (s currencySystem) unary(op string, x interface{}) (interface{}, error) {
+	m := x.(money)
+	amount, err := s.decimal.unary(op, m.amount)
+	return s.wrap(amount, m.currency, err)
+}
+
+func (s currencySystem) operate(op string, left, right interface{}) (interface{}, error) {
+	l, r := left.(money), right.(money)
+	mismatch := &CurrencyError{Op: op, Left: l.currency, Right: r.currency}
+
+	// the currency of the result, which plain numbers don't change
+	currency := l.currency
+	if currency == "" {
+		currency = r.currency
+	}
+
+	switch op {
+	case add, subtract, modulus:
+		if l.currency != "" && r.currency != "" && l.currency != r.currency {
+			return nil, mismatch
+		}
+	case multiply:
+		if l.currency != "" && r.currency != "" {
+			// money is only multiplied by plain numbers, whatever the currencies
+			return nil, ErrMoneyProduct
+		}
+	case divide, floorDivide:
+		switch {
+		case r.currency == "":
+		case l.currency == r.currency:
+			// the ratio of two amounts of the same currency is a plain number
+			currency = ""
+		default:
+			return nil, mismatch
+		}
+	case power:
+		if currency != "" {
+			return nil, mismatch
+		}
+	case convertTo:
+		return s.convert(l, r)
+	}
+
+	amount, err := s.decimal.operate(op, l.amount, r.amount)
+	return s.wrap(amount, currency, err)
+}
+
+// convert converts an amount of money to the currency of target, whose amount is ignored.
+func (s currencySystem) convert(m, target money) (interface{}, error) {
+	switch {
+	case m.currency == "" || target.currency == "":
+		return nil, &CurrencyError{Op: convertTo, Left: m.currency, Right: target.currency}
+	case m.currency == target.currency:
+		return m, nil
+	}
+
+	rate, err := s.rates.Rate(m.currency, target.currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rate is converted by its shortest decimal form, as written in the list of rates it came from
+	factor, err := s.decimal.fromFloat(rate)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := s.decimal.operate(multiply, m.amount, factor)
+	return s.wrap(amount, target.currency, err)
+}
+
+// compare only compares amounts of the same currency, or plain numbers with each other.
+func (s currencySystem) compare(op string, left, right interface{}) (bool, error) {
+	l, r := left.(money), right.(money)
+	if l.currency != r.currency {
+		return false, &CurrencyError{Op: op, Left: l.currency, Right: r.currency}
+	}
+	return s.decimal.compare(op, l.amount, r.amount)
+}
+
+func (s currencySystem) call(name string, fn function, args []interface{}) (interface{}, error) {
+	amounts := make([]interface{}, len(args))
+	for i, arg := range args {
+		amounts[i] = arg.(money).amount
+	}
+	currency := args[0].(money).currency
+
+	switch name {
+	case "abs", "floor", "ceil", "round", "trunc", "min", "max":
+		// amounts keep their currency, but can only be compared with amounts of the same one
+		for _, arg := range args[1:] {
+			if other := arg.(money).currency; other != currency {
+				return nil, &CurrencyError{Op: name, Left: currency, Right: other}
+			}
+		}
+	default:
+		for _, arg := range args {
+			if other := arg.(money).currency; other != "" {
+				return nil, &CurrencyError{Op: name, Left: other}
+			}
+		}
+	}
+
+	amount, err := s.decimal.call(name, fn, amounts)
+	return s.wrap(amount, currency, err)
+}
+
+func (s currencySystem) float(x interface{}) float64 {
+	return s.decimal.float(x.(money).amount)
+}
+
+// integer only returns the value of plain numbers.
+func (s currencySystem) integer(x interface{}) (*big.Int, bool) {
+	m := x.(money)
+	if m.currency != "" {
+		return nil, false
+	}
+	return s.decimal.integer(m.amount)
+}
+
+// format writes an amount of money rounded to two decimal places, followed by its currency,
+// such as 12.50 USD. Plain numbers are written as in decimal mode.
+func (s currencySystem) format(x interface{}) string {
+	m := x.(money)
+	if m.currency == "" {
+		return s.decimal.format(m.amount)
+	}
+
+	d := m.amount.(decimalNumber)
+	if d.inf != 0 {
+		return s.decimal.format(d) + whitespace + m.currency
+	}
+	return fixedDecimal(d, currencyPlaces) + whitespace + m.currency
+}
+
+// fixedDecimal writes a finite number rounded half to even to the given number of decimal places,
+// keeping trailing zeros, as in 12.50.
+func fixedDecimal(d decimalNumber, places int) string {
+	coef := d.coef
+	if d.exp >= -places {
+		coef = new(big.Int).Mul(coef, pow10(d.exp+places))
+	} else {
+		coef = dropDigits(coef, -places-d.exp)
+	}
+
+	digits := new(big.Int).Abs(coef).String()
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+
+	text := digits[:len(digits)-places] + decimal + digits[len(digits)-places:]
+	if coef.Sign() < 0 {
+		text = subtract + text
+	}
+	return text
+}
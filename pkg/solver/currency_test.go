@@ -0,0 +1,214 @@
+package solver
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// testRates are the exchange rates of the currency tests, as amounts worth one US dollar.
+var testRates = StaticRates{"USD": 1, "EUR": 0.92, "GBP": 0.8, "JPY": 150}
+
+func TestParse_currency(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"symbol", "$12.50", "(12.5 * USD)"},
+		{"code", "15 EUR", "(15 * EUR)"},
+		{"negated symbol", "-€3", "(-(3 * EUR))"},
+		{"amount binds tighter than division", "100 EUR / 50 EUR", "((100 * EUR) / (50 * EUR))"},
+		{"conversion", "100 USD to EUR", "((100 * USD) to EUR)"},
+		{"conversion alias", "£5 in JPY", "((5 * GBP) to JPY)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression, WithCurrency(testRates))
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_currencyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+		wantOffset int
+		wantLength int
+	}{
+		{"symbol outside currency mode", "$12.50", nil, ErrCurrencySymbol, 0, 1},
+		{"symbol in units mode", "2 * €3", []Option{WithUnits()}, ErrCurrencySymbol, 4, 3},
+		{"symbol without amount", "£ + 1", []Option{WithCurrency(testRates)}, ErrIllegalConsecutiveOperator, 3, 1},
+		{"symbol after amount", "5 $", []Option{WithCurrency(testRates)}, ErrMalformedExp, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression, tt.opts...)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+			if syntaxErr.Offset != tt.wantOffset || syntaxErr.Length != tt.wantLength {
+				t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", syntaxErr.Offset, syntaxErr.Length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestSolve_currency(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantResult string
+	}{
+		{"symbol", "$12.50", "12.50 USD"},
+		{"code", "15 EUR", "15.00 EUR"},
+		{"sum", "$12.50 + $0.75", "13.25 USD"},
+		{"decimal arithmetic", "$0.10 + $0.20 - $0.30", "0.00 USD"},
+		{"scaling", "3 * €19.99", "59.97 EUR"},
+		{"plain number added", "$5 + 1", "6.00 USD"},
+		{"rounded to cents", "$10 / 3", "3.33 USD"},
+		{"half to even", "$0.125 + $0", "0.12 USD"},
+		{"negative amount", "-£2.50 * 2", "-5.00 GBP"},
+		{"ratio", "100 EUR / 40 EUR", "2.5"},
+		{"conversion", "100 USD to EUR", "92.00 EUR"},
+		{"conversion through base", "100 EUR to GBP", "86.96 GBP"},
+		{"conversion alias", "$2 in JPY", "300.00 JPY"},
+		{"same currency", "15 EUR to EUR", "15.00 EUR"},
+		{"converted sum", "$10 + (5 EUR to USD)", "15.43 USD"},
+		{"rounding", "round($2.50) + floor($1.99)", "4.00 USD"},
+		{"maximum", "max($3, $5, $4)", "5.00 USD"},
+		{"plain function", "sqrt(16) * $1", "4.00 USD"},
+		{"program", "price = $19.99; tax = price * 0.2; price + tax", "23.99 USD"},
+		{"user-defined function", "net(gross) = gross / 1.2; net(€120)", "100.00 EUR"},
+		{"variable shadows code", "USD = 2; USD * 3", "6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, WithCurrency(testRates))
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_currencyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    CurrencyError
+	}{
+		{"sum", "$1 + 1 EUR", CurrencyError{Op: add, Left: "USD", Right: "EUR"}},
+		{"difference", "£5 - ¥5", CurrencyError{Op: subtract, Left: "GBP", Right: "JPY"}},
+		{"ratio", "$2 / 1 EUR", CurrencyError{Op: divide, Left: "USD", Right: "EUR"}},
+		{"reciprocal", "1 / $2", CurrencyError{Op: divide, Left: "", Right: "USD"}},
+		{"power", "$2^2", CurrencyError{Op: power, Left: "USD", Right: ""}},
+		{"conversion of plain number", "100 to EUR", CurrencyError{Op: convertTo, Left: "", Right: "EUR"}},
+		{"function", "sqrt($4)", CurrencyError{Op: "sqrt", Left: "USD"}},
+		{"comparison", "min($1, 1 EUR)", CurrencyError{Op: "min", Left: "USD", Right: "EUR"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, WithCurrency(testRates))
+
+			var currencyErr *CurrencyError
+			if !errors.As(err, &currencyErr) || !errors.Is(err, ErrCurrency) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, ErrCurrency)
+			}
+			if *currencyErr != tt.wantErr {
+				t.Errorf("\nGot:\t%+v\nWant:\t%+v", *currencyErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEval_moneyProduct(t *testing.T) {
+	for _, expr := range []string{"$2 * $3", "$2 * 3 EUR"} {
+		_, err := Eval(expr, WithCurrency(testRates))
+
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) || !errors.Is(err, ErrMoneyProduct) || errors.Is(err, ErrCurrency) {
+			t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrMoneyProduct)
+		}
+	}
+}
+
+func TestEval_unknownCurrency(t *testing.T) {
+	_, err := Eval("100 USD to CHF", WithCurrency(testRates))
+
+	var evalErr *EvalError
+	if !errors.As(err, &evalErr) || !errors.Is(err, ErrUnknownCurrency) {
+		t.Fatalf("\nGot:\t%v\nWant:\t%v", err, ErrUnknownCurrency)
+	}
+	if evalErr.Offset != 0 || evalErr.Length != 14 {
+		t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", evalErr.Offset, evalErr.Length, 0, 14)
+	}
+}
+
+func TestResult_Currency(t *testing.T) {
+	tests := []struct {
+		expression   string
+		opts         []Option
+		wantFloat    float64
+		wantCurrency string
+	}{
+		{"$12.50 * 2", []Option{WithCurrency(testRates)}, 25, "USD"},
+		{"100 USD to EUR", []Option{WithCurrency(testRates)}, 92, "EUR"},
+		{"$3 / $2", []Option{WithCurrency(testRates)}, 1.5, ""},
+		{"1.5", nil, 1.5, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Eval(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.Float() != tt.wantFloat || result.Currency() != tt.wantCurrency {
+				t.Errorf("\nGot:\t%v, %q\nWant:\t%v, %q", result.Float(), result.Currency(), tt.wantFloat, tt.wantCurrency)
+			}
+		})
+	}
+}
+
+func Test_fixedDecimal(t *testing.T) {
+	tests := []struct {
+		x    decimalNumber
+		want string
+	}{
+		{decimalNumber{coef: big.NewInt(0)}, "0.00"},
+		{decimalNumber{coef: big.NewInt(5), exp: 2}, "500.00"},
+		{decimalNumber{coef: big.NewInt(125), exp: -1}, "12.50"},
+		{decimalNumber{coef: big.NewInt(-7), exp: -3}, "-0.01"},
+		{decimalNumber{coef: big.NewInt(-4), exp: -3}, "0.00"},
+		{decimalNumber{coef: big.NewInt(135), exp: -3}, "0.14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := fixedDecimal(tt.x, 2); got != tt.want {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,577 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxPlainExponent is the largest decimal exponent of a number written out in full in results.
+// Numbers beyond the range of float64 are written in scientific notation instead.
+const maxPlainExponent = 308
+
+// maxDecimalExponent bounds the exponents of decimal numbers, beyond which results overflow.
+const maxDecimalExponent = 1 << 40
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// decimalNumber is the number coef × 10^exp, or an infinity of the sign of inf if inf isn't 0.
+// Unlike binary floating-point numbers, it represents decimal fractions such as 0.1 exactly.
+type decimalNumber struct {
+	coef *big.Int
+	exp  int
+	inf  int
+}
+
+// decimalSystem evaluates expressions with decimal numbers rounded to a fixed number of significant digits.
+// Sums, differences and products of decimals are exact as long as they fit in the digits, so 0.1 + 0.2 is 0.3,
+// while quotients such as 1/3 are rounded half to even.
+type decimalSystem struct {
+	digits int
+}
+
+func (s decimalSystem) literal(text string) (interface{}, error) {
+	coef, exp, err := parseDecimal(text)
+	if err != nil {
+		return nil, err
+	}
+	return s.round(coef, exp)
+}
+
+// parseDecimal splits the text of a number literal, such as "1_234.5e-3" or "0xFF",
+// into the integer coefficient and the power of ten it is multiplied by.
+func parseDecimal(text string) (*big.Int, int, error) {
+	if len(text) > 2 && radixPrefixes[text[:2]] != 0 {
+		// hexadecimal, octal and binary literals are integers
+		coef, ok := new(big.Int).SetString(text, 0)
+		if !ok {
+			return nil, 0, ErrMalformedExp
+		}
+		return coef, 0, nil
+	}
+
+	mantissa, exp := strings.ReplaceAll(text, "_", ""), 0
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.Atoi(mantissa[i+1:]); err != nil {
+			return nil, 0, ErrOverflow
+		}
+		mantissa = mantissa[:i]
+	}
+
+	if i := strings.Index(mantissa, decimal); i >= 0 {
+		exp -= len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+
+	coef, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return nil, 0, ErrMalformedExp
+	}
+	return coef, exp, nil
+}
+
+// fromFloat converts x by its shortest decimal form rather than its exact binary value,
+// so that a variable of 0.1 is exactly 0.1.
+func (s decimalSystem) fromFloat(x float64) (interface{}, error) {
+	switch {
+	case math.IsNaN(x):
+		return nil, ErrNotANumber
+	case math.IsInf(x, 1):
+		return decimalNumber{inf: 1}, nil
+	case math.IsInf(x, -1):
+		return decimalNumber{inf: -1}, nil
+	}
+	return s.literal(strconv.FormatFloat(x, 'g', -1, 64))
+}
+
+func (s decimalSystem) constant(name string) (interface{}, error) {
+	if text, ok := preciseConstants[name]; ok {
+		return s.literal(text)
+	}
+	return s.fromFloat(constants[name])
+}
+
+func (s decimalSystem) owns(x interface{}) bool {
+	_, ok := x.(decimalNumber)
+	return ok
+}
+
+func (s decimalSystem) unary(op string, x interface{}) (interface{}, error) {
+	if op != subtract {
+		return nil, unsupportedOperator(op)
+	}
+	return s.negate(x)
+}
+
+// negate returns a number with the opposite sign.
+func (s decimalSystem) negate(x interface{}) (interface{}, error) {
+	d := x.(decimalNumber)
+	if d.inf != 0 {
+		return decimalNumber{inf: -d.inf}, nil
+	}
+	return decimalNumber{coef: new(big.Int).Neg(d.coef), exp: d.exp}, nil
+}
+
+func (s decimalSystem) operate(op string, left, right interface{}) (interface{}, error) {
+	l, r := left.(decimalNumber), right.(decimalNumber)
+
+	if l.inf != 0 || r.inf != 0 {
+		// infinities follow the rules of float64
+		result, err := operate(op, s.float(l), s.float(r))
+		if err != nil {
+			return nil, err
+		}
+		return s.fromFloat(result)
+	}
+
+	switch op {
+	case add:
+		return s.add(l, r)
+	case subtract:
+		return s.add(l, decimalNumber{coef: new(big.Int).Neg(r.coef), exp: r.exp})
+	case multiply:
+		return s.round(new(big.Int).Mul(l.coef, r.coef), l.exp+r.exp)
+	case divide, floorDivide, modulus:
+		if r.coef.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+
+		switch op {
+		case divide:
+			return s.quo(l, r)
+		case floorDivide:
+			quotient, _, err := s.quoRem(l, r)
+			return quotient, err
+		default:
+			_, remainder, err := s.quoRem(l, r)
+			return remainder, err
+		}
+	case power:
+		return s.power(l, r)
+	}
+
+	return nil, unsupportedOperator(op)
+}
+
+// add returns the sum of two finite numbers.
+func (s decimalSystem) add(a, b decimalNumber) (interface{}, error) {
+	if a.coef.Sign() == 0 {
+		return s.round(b.coef, b.exp)
+	}
+	if b.coef.Sign() == 0 {
+		return s.round(a.coef, a.exp)
+	}
+
+	if magnitude(a) < magnitude(b) {
+		a, b = b, a
+	}
+
+	// a number too small to affect the rounded sum is replaced with a single digit of its sign
+	// just below the precision, so that the sum still rounds the right way
+	if lowest := magnitude(a) - s.digits - 2; magnitude(b) < lowest {
+		b = decimalNumber{coef: big.NewInt(int64(b.coef.Sign())), exp: lowest}
+	}
+
+	a, b = align(a, b)
+	return s.round(new(big.Int).Add(a.coef, b.coef), a.exp)
+}
+
+// quo returns the quotient of two finite numbers, rounded to the precision of the system.
+func (s decimalSystem) quo(l, r decimalNumber) (interface{}, error) {
+	// scale the dividend for the quotient to have at least one digit more than the precision
+	shift := s.digits + 2 + digitCount(r.coef) - digitCount(l.coef)
+	if shift < 0 {
+		shift = 0
+	}
+
+	dividend := new(big.Int).Mul(l.coef, pow10(shift))
+	quotient, remainder := new(big.Int).QuoRem(dividend, r.coef, new(big.Int))
+	exp := l.exp - r.exp - shift
+
+	if remainder.Sign() != 0 {
+		// a sticky digit makes an inexact quotient round the same way as the exact one
+		quotient.Mul(quotient, bigTen)
+		quotient.Add(quotient, big.NewInt(int64(dividend.Sign()*r.coef.Sign())))
+		exp--
+	}
+	return s.round(quotient, exp)
+}
+
+// quoRem returns the floor of the quotient of two finite numbers and the remainder
+// of the quotient truncated towards zero, which takes the sign of the dividend.
+func (s decimalSystem) quoRem(l, r decimalNumber) (interface{}, interface{}, error) {
+	if magnitude(l)-magnitude(r) > s.digits {
+		// the integer part of the quotient doesn't fit in the precision
+		return nil, nil, ErrOverflow
+	}
+
+	if magnitude(l) < magnitude(r)-1 {
+		// the dividend is smaller than the divisor
+		floor := int64(0)
+		if l.coef.Sign()*r.coef.Sign() < 0 {
+			floor = -1
+		}
+		return decimalNumber{coef: big.NewInt(floor)}, l, nil
+	}
+
+	l, r = align(l, r)
+	quotient, remainder := new(big.Int).QuoRem(l.coef, r.coef, new(big.Int))
+	if remainder.Sign() != 0 && remainder.Sign() != r.coef.Sign() {
+		quotient.Sub(quotient, bigOne)
+	}
+
+	floor, err := s.round(quotient, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	rem, err := s.round(remainder, l.exp)
+	return floor, rem, err
+}
+
+// power raises base to the power of exp. Integer powers are computed to the precision of the system,
+// and others to the precision of float64.
+func (s decimalSystem) power(base, exp decimalNumber) (interface{}, error) {
+	if err := checkPower(base.coef.Sign() == 0, exp.coef.Sign() < 0); err != nil {
+		return nil, err
+	}
+
+	n, isInt := decimalInt64(exp)
+	if !isInt {
+		result, err := operate(power, s.float(base), s.float(exp))
+		if err != nil {
+			return nil, err
+		}
+		return s.fromFloat(result)
+	}
+
+	one := decimalNumber{coef: big.NewInt(1)}
+	switch {
+	case n == 0:
+		return one, nil
+	case base.coef.Sign() == 0:
+		return base, nil
+	}
+
+	// the magnitude of the result must stay within the exponents of decimal numbers
+	if size := float64(magnitude(base)) * float64(n); math.Abs(size) > maxDecimalExponent {
+		if size > 0 {
+			return nil, ErrOverflow
+		}
+		return decimalNumber{coef: new(big.Int)}, nil
+	}
+
+	// exponentiation by squaring
+	result, square := one, base
+	for i := n; i != 0; i /= 2 {
+		if i%2 != 0 {
+			product, err := s.operate(multiply, result, square)
+			if err != nil {
+				return nil, err
+			}
+			result = product.(decimalNumber)
+		}
+
+		if i/2 != 0 {
+			product, err := s.operate(multiply, square, square)
+			if err != nil {
+				return nil, err
+			}
+			square = product.(decimalNumber)
+		}
+	}
+
+	if n < 0 {
+		return s.quo(one, result)
+	}
+	return result, nil
+}
+
+func (s decimalSystem) compare(op string, left, right interface{}) (bool, error) {
+	return compared(op, compareDecimals(left.(decimalNumber), right.(decimalNumber))), nil
+}
+
+func (s decimalSystem) call(name string, fn function, args []interface{}) (interface{}, error) {
+	x := args[0].(decimalNumber)
+	if x.inf != 0 {
+		return callFloat(s, fn, args)
+	}
+
+	switch name {
+	case "sqrt":
+		return s.sqrt(x)
+	case "abs":
+		return decimalNumber{coef: new(big.Int).Abs(x.coef), exp: x.exp}, nil
+	case "floor":
+		return s.floor(x), nil
+	case "ceil":
+		negated, _ := s.negate(x)
+		return s.negate(s.floor(negated.(decimalNumber)))
+	case "trunc":
+		return s.trunc(x), nil
+	case "round":
+		// halves are rounded away from zero
+		half := decimalNumber{coef: big.NewInt(int64(5 * x.coef.Sign())), exp: -1}
+		sum, err := s.add(x, half)
+		if err != nil {
+			return nil, err
+		}
+		return s.trunc(sum.(decimalNumber)), nil
+	case "min", "max":
+		result := x
+		for _, arg := range args[1:] {
+			cmp := compareDecimals(arg.(decimalNumber), result)
+			if name == "min" && cmp < 0 || name == "max" && cmp > 0 {
+				result = arg.(decimalNumber)
+			}
+		}
+		return result, nil
+	}
+
+	return callFloat(s, fn, args)
+}
+
+// sqrt returns the square root of a finite number.
+func (s decimalSystem) sqrt(x decimalNumber) (interface{}, error) {
+	if x.coef.Sign() < 0 {
+		return nil, ErrDomain
+	}
+
+	// scale the number for its root to have at least one digit more than the precision,
+	// keeping the exponent even so that it can be halved
+	shift := 2*s.digits + 2 - digitCount(x.coef)
+	if shift < 0 {
+		shift = 0
+	}
+	if (x.exp-shift)%2 != 0 {
+		shift++
+	}
+
+	scaled := new(big.Int).Mul(x.coef, pow10(shift))
+	root := new(big.Int).Sqrt(scaled)
+	exp := (x.exp - shift) / 2
+
+	if new(big.Int).Mul(root, root).Cmp(scaled) != 0 {
+		// a sticky digit makes an inexact root round the same way as the exact one
+		root.Mul(root, bigTen)
+		root.Add(root, bigOne)
+		exp--
+	}
+	return s.round(root, exp)
+}
+
+// trunc returns a finite number without its fractional part.
+func (s decimalSystem) trunc(x decimalNumber) decimalNumber {
+	switch {
+	case x.exp >= 0:
+		return x
+	case magnitude(x) <= 0:
+		return decimalNumber{coef: new(big.Int)}
+	}
+	return decimalNumber{coef: new(big.Int).Quo(x.coef, pow10(-x.exp))}
+}
+
+// floor returns the largest integer less than or equal to a finite number.
+func (s decimalSystem) floor(x decimalNumber) decimalNumber {
+	t := s.trunc(x)
+	if compareDecimals(x, t) < 0 {
+		// only numbers with a fractional part can be below their truncation, which has an exponent of 0
+		return decimalNumber{coef: new(big.Int).Sub(t.coef, bigOne)}
+	}
+	return t
+}
+
+// round rounds coef × 10^exp half to even to the precision of the system.
+func (s decimalSystem) round(coef *big.Int, exp int) (interface{}, error) {
+	if drop := digitCount(coef) - s.digits; drop > 0 {
+		coef, exp = dropDigits(coef, drop), exp+drop
+	}
+
+	if exp > maxDecimalExponent {
+		return nil, ErrOverflow
+	}
+	return decimalNumber{coef: coef, exp: exp}, nil
+}
+
+// dropDigits rounds an integer half to even to a multiple of 10^drop, and returns the multiple.
+func dropDigits(coef *big.Int, drop int) *big.Int {
+	divisor := pow10(drop)
+	quotient, remainder := new(big.Int).QuoRem(coef, divisor, new(big.Int))
+
+	// compare twice the remainder with the divisor to find which way to round
+	remainder.Abs(remainder).Lsh(remainder, 1)
+	if half := remainder.Cmp(divisor); half > 0 || half == 0 && quotient.Bit(0) == 1 {
+		quotient.Add(quotient, big.NewInt(int64(coef.Sign())))
+	}
+	return quotient
+}
+
+func (s decimalSystem) float(x interface{}) float64 {
+	d := x.(decimalNumber)
+	if d.inf != 0 {
+		return math.Inf(d.inf)
+	}
+
+	f, _ := strconv.ParseFloat(d.coef.String()+"e"+strconv.Itoa(d.exp), 64)
+	return f
+}
+
+func (s decimalSystem) integer(x interface{}) (*big.Int, bool) {
+	d := x.(decimalNumber)
+	switch {
+	case d.inf != 0:
+		return nil, false
+	case d.exp >= 0:
+		return new(big.Int).Mul(d.coef, pow10(d.exp)), true
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(d.coef, pow10(-d.exp), new(big.Int))
+	return quotient, remainder.Sign() == 0
+}
+
+func (s decimalSystem) format(x interface{}) string {
+	d := x.(decimalNumber)
+
+	switch {
+	// infinite results are written the same way as the inf constant
+	case d.inf > 0:
+		return "inf"
+	case d.inf < 0:
+		return "-inf"
+	}
+
+	digits := new(big.Int).Abs(d.coef).String()
+	sci := digits[:1] + decimal + digits[1:] + "e" + strconv.Itoa(d.exp+len(digits)-1)
+	if d.coef.Sign() < 0 {
+		sci = subtract + sci
+	}
+	return plainDecimal(sci)
+}
+
+// plainDecimal rewrites a number in scientific notation, such as "-1.2500e+02", as a decimal
+// without trailing zeros, such as "-125". Numbers beyond maxPlainExponent are only trimmed.
+func plainDecimal(sci string) string {
+	mantissa, exponent := sci, 0
+	if i := strings.IndexByte(sci, 'e'); i >= 0 {
+		mantissa = sci[:i]
+		exponent, _ = strconv.Atoi(sci[i+1:])
+	}
+
+	sign := ""
+	if strings.HasPrefix(mantissa, subtract) {
+		sign, mantissa = subtract, mantissa[1:]
+	}
+
+	if exponent > maxPlainExponent || exponent < -maxPlainExponent {
+		if strings.Contains(mantissa, decimal) {
+			mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), decimal)
+		}
+		return sign + mantissa + "e" + strconv.Itoa(exponent)
+	}
+
+	digits := strings.Replace(mantissa, decimal, "", 1)
+	// the decimal point follows the first digit before the exponent is applied
+	point := exponent + 1
+
+	var text string
+	switch {
+	case point <= 0:
+		text = "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		text = digits + strings.Repeat("0", point-len(digits))
+	default:
+		text = digits[:point] + decimal + digits[point:]
+	}
+
+	if strings.Contains(text, decimal) {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), decimal)
+	}
+	if text == "0" {
+		// zero has no sign
+		return text
+	}
+	return sign + text
+}
+
+// magnitude returns the number of digits of the integer part of a finite number,
+// which is negative for numbers with leading zeros after the decimal point.
+func magnitude(x decimalNumber) int {
+	return x.exp + digitCount(x.coef)
+}
+
+// align rewrites two finite numbers with the smaller of their exponents.
+func align(a, b decimalNumber) (decimalNumber, decimalNumber) {
+	switch {
+	case a.exp > b.exp:
+		a = decimalNumber{coef: new(big.Int).Mul(a.coef, pow10(a.exp-b.exp)), exp: b.exp}
+	case b.exp > a.exp:
+		b = decimalNumber{coef: new(big.Int).Mul(b.coef, pow10(b.exp-a.exp)), exp: a.exp}
+	}
+	return a, b
+}
+
+// compareDecimals returns -1, 0 or +1 depending on whether a is less than, equal to or greater than b.
+func compareDecimals(a, b decimalNumber) int {
+	if a.inf != 0 || b.inf != 0 {
+		return a.inf - b.inf
+	}
+
+	if a.coef.Sign() != b.coef.Sign() || a.coef.Sign() == 0 {
+		return a.coef.Sign() - b.coef.Sign()
+	}
+
+	if ma, mb := magnitude(a), magnitude(b); ma != mb {
+		// numbers of the same sign and different magnitudes
+		if (ma > mb) == (a.coef.Sign() > 0) {
+			return 1
+		}
+		return -1
+	}
+
+	a, b = align(a, b)
+	return a.coef.Cmp(b.coef)
+}
+
+// decimalInt64 returns a finite number as an int64 and whether it is an integer in the range of int64.
+func decimalInt64(x decimalNumber) (int64, bool) {
+	if x.exp < 0 {
+		if magnitude(x) <= 0 {
+			return 0, x.coef.Sign() == 0
+		}
+
+		quotient, remainder := new(big.Int).QuoRem(x.coef, pow10(-x.exp), new(big.Int))
+		return quotient.Int64(), remainder.Sign() == 0 && quotient.IsInt64()
+	}
+
+	if magnitude(x) > 19 {
+		return 0, false
+	}
+	n := new(big.Int).Mul(x.coef, pow10(x.exp))
+	return n.Int64(), n.IsInt64()
+}
+
+// digitCount returns the number of decimal digits of an integer, which is 1 for 0.
+func digitCount(x *big.Int) int {
+	if x.Sign() == 0 {
+		return 1
+	}
+
+	// estimate the count from the number of bits, then correct it
+	count := int(float64(x.BitLen()-1)*math.Log10(2)) + 1
+	abs := new(big.Int).Abs(x)
+	if abs.Cmp(pow10(count)) >= 0 {
+		count++
+	} else if count > 1 && abs.Cmp(pow10(count-1)) < 0 {
+		count--
+	}
+	return count
+}
+
+// pow10 returns 10 raised to a non-negative power.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,154 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSolve_precision(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		digits     int
+		wantResult string
+	}{
+		{"decimal fractions", "0.1 + 0.2", 34, "0.3"},
+		{"float64 artifact", "2(3.54 * 2.00 -1000 /200) / (20 + 30 * 2)", 34, "0.052"},
+		{"repeating fraction", "1/3", 20, "0.33333333333333333333"},
+		{"rounded to digits", "2/3", 5, "0.66667"},
+		{"rounded quotient", "1/3 * 3", 34, "0.9999999999999999999999999999999999"},
+		{"rounded half to even", "0.365 + 0.375", 2, "0.74"},
+		{"large integer", "2^100", 34, "1267650600228229401496703205376"},
+		{"negative power", "2^-3", 34, "0.125"},
+		{"precise constant", "pi", 30, "3.14159265358979323846264338328"},
+		{"precise square root", "sqrt(2)", 30, "1.41421356237309504880168872421"},
+		{"floor division", "-7 // 2", 34, "-4"},
+		{"modulus", "-7.5 % 2", 34, "-1.5"},
+		{"rounding", "round(-2.5) + ceil(-0.5) + floor(2.5)", 34, "-1"},
+		{"minimum", "min(0.3, 0.1, 0.2)", 34, "0.1"},
+		{"fallback to float64", "sin(pi/2) + 0.1", 34, "1.1"},
+		{"beyond float64", "10^400 * 2", 34, "2e400"},
+		{"infinity", "-inf", 34, "-inf"},
+		{"scientific and hexadecimal literals", "1.5e-3 + 0x10", 34, "16.0015"},
+		{"literal beyond float64", "1e400 + 1e399", 34, "1.1e400"},
+		{"program", "x = 0.1; y = x * 3; y - 0.3", 34, "0"},
+		{"user-defined function", "f(x) = x / 10; f(1) + f(2)", 34, "0.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, WithPrecision(tt.digits))
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestSolve_noPrecision(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantResult string
+	}{
+		{"float64", "0.1 + 0.2", []Option{WithPrecision(0)}, "0.30000000000000004"},
+		{"after precision", "0.1 + 0.2", []Option{WithPrecision(34), WithPrecision(0)}, "0.30000000000000004"},
+		{"after rational", "1/3", []Option{WithRational(Fraction, RejectInexact), WithPrecision(0)}, "1/3"},
+		{"after integer", "2^100", []Option{WithInteger(), WithPrecision(-1)}, "1267650600228229401496703205376"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_precisionErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+	}{
+		{"division by zero", "1 / (0.1 + 0.2 - 0.3)", ErrDivisionByZero},
+		{"negative power of zero", "0^-1", ErrDivisionByZero},
+		{"not a number", "inf - inf", ErrNotANumber},
+		{"domain", "sqrt(-0.1)", ErrDomain},
+		{"undefined name", "x + 1", ErrUndefinedName},
+		{"recursion", "f(x) = f(x); f(1)", ErrCallDepthExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, WithPrecision(34))
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalWithEnv_precision(t *testing.T) {
+	env := NewEnv(map[string]float64{"price": 0.1})
+
+	// variables are converted by their shortest decimal form
+	result, err := EvalWithEnv("total = price * 3", env, WithPrecision(34))
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "0.3" || result.Float() != 0.3 {
+		t.Errorf("\nGot:\t%s, %v\nWant:\t%s, %v", result, result.Float(), "0.3", 0.3)
+	}
+
+	// assignments keep their exact value for later evaluations in the same precision
+	result, err = EvalWithEnv("total / 3 * 3", env, WithPrecision(34))
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "0.3" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "0.3")
+	}
+
+	// and their float64 approximation otherwise
+	if value, _ := env.Get("total"); value != 0.3 {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", value, 0.3)
+	}
+}
+
+func Test_plainDecimal(t *testing.T) {
+	tests := []struct {
+		sci  string
+		want string
+	}{
+		{"1.2500e+02", "125"},
+		{"-1.2500e+02", "-125"},
+		{"1.2340e-03", "0.001234"},
+		{"1.0000e+00", "1"},
+		{"-0.0000e+00", "0"},
+		{"1.5000e+01", "15"},
+		{"5e+00", "5"},
+		{"1.2000e+400", "1.2e400"},
+		{"1.0000e-400", "1e-400"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sci, func(t *testing.T) {
+			if got := plainDecimal(tt.sci); got != tt.want {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package solver
+
+import "sort"
+
+// Env is a scope of named values and user-defined functions which expressions can refer to.
+// Names in an Env shadow the constants and library functions of the same name.
+// The zero value is an empty scope ready to use.
+// Like a nil map, a nil *Env is an empty scope which can be read but not written:
+// looking names up finds nothing and removing them does nothing, while storing a value or a function panics.
+type Env struct {
+	vars map[string]float64
+	// exact holds the exact values of variables assigned by expressions evaluated in a number system
+	// other than float64, such as with WithPrecision, and the values which aren't numbers.
+	// vars holds their approximations.
+	exact map[string]interface{}
+	funcs map[string]*userFunction
+	// parent is the scope names are looked up in when they aren't found in this one.
+	parent *Env
+}
+
+// userFunction is a function defined by an expression.
+type userFunction struct {
+	name   string
+	params []string
+	body   Node
+	// source is the expression the body was parsed from.
+	source string
+	// code is the body compiled for the virtual machine.
+	code *chunk
+}
+
+// newUserFunction returns the function defined by a node parsed from source.
+func newUserFunction(def *FuncDefNode, source string) *userFunction {
+	return &userFunction{name: def.Name, params: def.Params, body: def.Body, source: source, code: compileFunction(source, def)}
+}
+
+// NewEnv returns a scope holding the given values.
+func NewEnv(vars map[string]float64) *Env {
+	env := &Env{}
+	for name, value := range vars {
+		env.Set(name, value)
+	}
+	return env
+}
+
+// Set assigns a value to a name, replacing any previous value.
+func (e *Env) Set(name string, value float64) {
+	if e.vars == nil {
+		e.vars = make(map[string]float64)
+	}
+	e.vars[name] = value
+	delete(e.exact, name)
+}
+
+// setExact assigns a number of a number system to a name along with its float64 approximation.
+func (e *Env) setExact(name string, approx float64, exact interface{}) {
+	e.Set(name, approx)
+	if e.exact == nil {
+		e.exact = make(map[string]interface{})
+	}
+	e.exact[name] = exact
+}
+
+// SetBool assigns a boolean to a name, replacing any previous value.
+// Booleans are distinct from numbers in expressions, and Get returns 1 for true and 0 for false.
+func (e *Env) SetBool(name string, value bool) {
+	e.SetValue(name, Bool(value))
+}
+
+// SetValue assigns a value of any type to a name, replacing any previous value.
+// Get returns 1 for true and 0 for false, and NaN for strings and lists.
+func (e *Env) SetValue(name string, value Value) {
+	if n, ok := value.(Number); ok {
+		e.Set(name, float64(n))
+		return
+	}
+	e.setExact(name, approximate(value), value)
+}
+
+// Value returns the value assigned to a name and whether it exists.
+// Numbers assigned by evaluations in other number systems are approximated as float64.
+func (e *Env) Value(name string) (Value, bool) {
+	if e == nil {
+		return nil, false
+	}
+
+	if value, ok := e.exact[name].(Value); ok {
+		return publicValue(value), true
+	}
+	num, ok := e.vars[name]
+	if !ok {
+		return nil, false
+	}
+	return Number(num), true
+}
+
+// setValue assigns a value computed by the virtual machine to a name.
+func (e *Env) setValue(name string, v value) {
+	if v.ref != nil {
+		e.SetValue(name, v.ref)
+		return
+	}
+	e.Set(name, v.num)
+}
+
+// Get returns the value assigned to a name and whether it exists.
+func (e *Env) Get(name string) (float64, bool) {
+	if e == nil {
+		return 0, false
+	}
+
+	value, ok := e.vars[name]
+	return value, ok
+}
+
+// Delete removes a name from the scope.
+func (e *Env) Delete(name string) {
+	if e == nil {
+		return
+	}
+	delete(e.vars, name)
+	delete(e.exact, name)
+}
+
+// Names returns the names in the scope in alphabetical order.
+func (e *Env) Names() []string {
+	if e == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(e.vars))
+	for name := range e.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Define parses body as an expression of the given parameters and stores it as a function,
+// replacing any previous function of the same name. The body may only refer to its parameters
+// and to names already defined in the scope or as constants.
+// The name and parameters must be valid names, and the offsets of errors in the body are relative to it.
+func (e *Env) Define(name string, params []string, body string, opts ...Option) error {
+	if !isName(name) {
+		return newSyntaxError(name, ErrInvalidName, 0, len(name))
+	}
+	for i, param := range params {
+		if !isName(param) {
+			return newSyntaxError(param, ErrInvalidName, 0, len(param))
+		}
+		for _, existing := range params[:i] {
+			if existing == param {
+				// each parameter must have a distinct name
+				return newSyntaxError(param, ErrMalformedExp, 0, len(param))
+			}
+		}
+	}
+
+	node, err := Parse(body, opts...)
+	if err != nil {
+		return err
+	}
+
+	switch node.(type) {
+	case *ProgramNode, *AssignNode, *FuncDefNode:
+		// the body is a single expression rather than statements
+		return newSyntaxError(body, ErrMalformedExp, node.Pos(), node.End())
+	}
+
+	def := &FuncDefNode{span: span{node.Pos(), node.End()}, Name: name, Params: params, Body: node}
+	if undefined := undefinedNames(def, e); len(undefined) > 0 {
+		return newUndefinedEvalError(body, undefined)
+	}
+
+	e.define(def, body)
+	return nil
+}
+
+// Undefine removes a function from the scope.
+func (e *Env) Undefine(name string) {
+	if e == nil {
+		return
+	}
+	delete(e.funcs, name)
+}
+
+// Functions returns the names of the functions defined in the scope in alphabetical order.
+func (e *Env) Functions() []string {
+	if e == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(e.funcs))
+	for name := range e.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// define stores the function defined by a node parsed from source.
+func (e *Env) define(def *FuncDefNode, source string) {
+	e.store(def.Name, newUserFunction(def, source))
+}
+
+// store adds a function to the scope, replacing any previous function of the same name.
+func (e *Env) store(name string, fn *userFunction) {
+	if e.funcs == nil {
+		e.funcs = make(map[string]*userFunction)
+	}
+	e.funcs[name] = fn
+}
+
+// lookup returns the value of a name from the scope or its parents, falling back to the constants.
+func (e *Env) lookup(name string) (float64, bool) {
+	for scope := e; scope != nil; scope = scope.parent {
+		if value, ok := scope.vars[name]; ok {
+			return value, true
+		}
+	}
+
+	value, ok := constants[name]
+	return value, ok
+}
+
+// lookupValue returns the value of a name from the scope or its parents, falling back to the constants.
+func (e *Env) lookupValue(name string) (value, bool) {
+	for scope := e; scope != nil; scope = scope.parent {
+		if num, ok := scope.vars[name]; ok {
+			if ref, ok := scope.exact[name].(Value); ok {
+				return value{ref: ref}, true
+			}
+			return number(num), true
+		}
+	}
+
+	num, ok := constants[name]
+	return number(num), ok
+}
+
+// lookupFunction returns the user-defined function of a name from the scope or its parents.
+func (e *Env) lookupFunction(name string) (*userFunction, bool) {
+	for scope := e; scope != nil; scope = scope.parent {
+		if fn, ok := scope.funcs[name]; ok {
+			return fn, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,163 @@
+package solver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	var env Env
+
+	if _, ok := env.Get("x"); ok {
+		t.Errorf("\nGot:\tx defined\nWant:\tx undefined")
+	}
+
+	env.Set("x", 2)
+	env.Set("y", 3)
+	env.Set("x", 4)
+
+	if value, ok := env.Get("x"); !ok || value != 4 {
+		t.Errorf("\nGot:\t%v, %t\nWant:\t%v, %t", value, ok, 4.0, true)
+	}
+
+	if names := env.Names(); !reflect.DeepEqual(names, []string{"x", "y"}) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", names, []string{"x", "y"})
+	}
+
+	env.Delete("x")
+	if _, ok := env.Get("x"); ok {
+		t.Errorf("\nGot:\tx defined\nWant:\tx undefined")
+	}
+}
+
+func TestEnv_nil(t *testing.T) {
+	var env *Env
+
+	if _, ok := env.Get("x"); ok {
+		t.Errorf("\nGot:\tx defined\nWant:\tx undefined")
+	}
+
+	if value, ok := env.lookup("pi"); !ok || value != constants["pi"] {
+		t.Errorf("\nGot:\t%v, %t\nWant:\t%v, %t", value, ok, constants["pi"], true)
+	}
+
+	if names := env.Names(); len(names) != 0 {
+		t.Errorf("\nGot:\t%v\nWant:\t[]", names)
+	}
+
+	if _, ok := env.Value("x"); ok {
+		t.Errorf("\nGot:\tx defined\nWant:\tx undefined")
+	}
+
+	if functions := env.Functions(); len(functions) != 0 {
+		t.Errorf("\nGot:\t%v\nWant:\t[]", functions)
+	}
+}
+
+func TestEnv_Define(t *testing.T) {
+	env := NewEnv(map[string]float64{"rate": 0.2})
+
+	if err := env.Define("tax", []string{"amount"}, "amount * rate"); err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	if err := env.Define("net", []string{"amount"}, "amount - tax(amount)"); err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	if err := env.Define("bad", []string{"x"}, "x * y"); !errors.Is(err, ErrUndefinedName) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrUndefinedName)
+	}
+
+	if err := env.Define("bad", []string{"x"}, "x *"); !errors.Is(err, ErrIllegalEnd) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrIllegalEnd)
+	}
+
+	if names := env.Functions(); !reflect.DeepEqual(names, []string{"net", "tax"}) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", names, []string{"net", "tax"})
+	}
+
+	result, err := EvalWithEnv("net(100)", env)
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "80" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "80")
+	}
+
+	env.Undefine("tax")
+	if _, err := EvalWithEnv("net(100)", env); !errors.Is(err, ErrUnknownFunction) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrUnknownFunction)
+	}
+}
+
+func TestEnv_Define_errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		fnName     string
+		params     []string
+		body       string
+		wantErr    error
+		wantOffset int
+		wantLength int
+	}{
+		{"name of a number", "2f", []string{"x"}, "x", ErrInvalidName, 0, 2},
+		{"keyword name", "if", []string{"x"}, "x", ErrInvalidName, 0, 2},
+		{"empty name", "", []string{"x"}, "x", ErrInvalidName, 0, 0},
+		{"operator parameter", "f", []string{"x", "xor"}, "x", ErrInvalidName, 0, 3},
+		{"expression parameter", "f", []string{"x + y"}, "x", ErrInvalidName, 0, 5},
+		{"repeated parameter", "f", []string{"x", "x"}, "x", ErrMalformedExp, 0, 1},
+		{"error in body", "f", []string{"x"}, "x * * 2", ErrIllegalConsecutiveOperator, 4, 1},
+		{"statements in body", "f", []string{"x"}, "y = x; y", ErrMalformedExp, 0, 8},
+		{"undefined name in body", "f", []string{"x"}, "x + y", ErrUndefinedName, 4, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Env{}).Define(tt.fnName, tt.params, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+
+			var offset, length int
+			var syntaxErr *SyntaxError
+			var evalErr *EvalError
+			switch {
+			case errors.As(err, &syntaxErr):
+				offset, length = syntaxErr.Offset, syntaxErr.Length
+			case errors.As(err, &evalErr):
+				offset, length = evalErr.Offset, evalErr.Length
+			}
+
+			if offset != tt.wantOffset || length != tt.wantLength {
+				t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", offset, length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestEnv_nil_writes(t *testing.T) {
+	var env *Env
+
+	// removing names from an empty scope does nothing
+	env.Delete("x")
+	env.Undefine("f")
+
+	writes := map[string]func(){
+		"Set":      func() { env.Set("x", 1) },
+		"SetBool":  func() { env.SetBool("x", true) },
+		"SetValue": func() { env.SetValue("x", String("a")) },
+		"Define":   func() { _ = env.Define("f", []string{"x"}, "x") },
+	}
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("\nGot:\tno panic\nWant:\tpanic")
+				}
+			}()
+			write()
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError describes where and why an expression is malformed.
+// It wraps one of the Err sentinels so that it can be matched with errors.Is.
+type SyntaxError struct {
+	// Err is the sentinel error describing the kind of problem.
+	Err error
+	// Msg is a human-readable description of the problem.
+	Msg string
+	// Offset is the byte offset of the offending text in the expression.
+	Offset int
+	// Length is the length in bytes of the offending text.
+	// It is 0 when the problem is the end of the expression.
+	Length int
+	// Column is the 1-based position in characters of the offending text.
+	Column int
+	// Statement is the 1-based index of the statement containing the offending text
+	// if the expression is a program of several statements, and 0 otherwise.
+	Statement int
+	// Expected is the token which was required where the problem was found,
+	// such as ")" for an unclosed parenthesis. It is empty if no single token was.
+	Expected string
+}
+
+func (e *SyntaxError) Error() string {
+	msg := describeLocation(e.Msg, e.Column, e.Statement)
+	if e.Expected != "" {
+		msg = fmt.Sprintf("%s, expected %q", msg, e.Expected)
+	}
+	return msg
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// EvalError describes a failure while computing the value of a well-formed expression,
+// such as a division by zero. It wraps one of the Err sentinels so that it can be matched with errors.Is.
+type EvalError struct {
+	// Err is the sentinel error describing the kind of problem.
+	Err error
+	// Msg is a human-readable description of the problem.
+	Msg string
+	// Offset is the byte offset of the sub-expression that failed.
+	Offset int
+	// Length is the length in bytes of the sub-expression that failed.
+	Length int
+	// Column is the 1-based position in characters of the sub-expression that failed.
+	Column int
+	// Statement is the 1-based index of the statement containing the sub-expression that failed
+	// if the expression is a program of several statements, and 0 otherwise.
+	Statement int
+}
+
+func (e *EvalError) Error() string {
+	return describeLocation(e.Msg, e.Column, e.Statement)
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// newEvalError returns an EvalError for the sub-expression of expr represented by node.
+func newEvalError(expr string, err error, node Node) *EvalError {
+	return &EvalError{
+		Err:    err,
+		Msg:    fmt.Sprintf("%s: %q", err, expr[node.Pos():node.End()]),
+		Offset: node.Pos(),
+		Length: node.End() - node.Pos(),
+		Column: utf8.RuneCountInString(expr[:node.Pos()]) + 1,
+	}
+}
+
+// newOperatorEvalError returns an EvalError for a binary node as newEvalError does,
+// except that a TypeError points at the operator, so that "1 + 2 + true" is reported at the second "+".
+func newOperatorEvalError(expr string, err error, node Node) *EvalError {
+	binary, ok := node.(*BinaryNode)
+	if _, isTypeErr := err.(*TypeError); !ok || !isTypeErr || binary.OpEnd == binary.OpPos {
+		return newEvalError(expr, err, node)
+	}
+
+	return &EvalError{
+		Err:    err,
+		Msg:    fmt.Sprintf("%s: %q", err, expr[binary.OpPos:binary.OpEnd]),
+		Offset: binary.OpPos,
+		Length: binary.OpEnd - binary.OpPos,
+		Column: utf8.RuneCountInString(expr[:binary.OpPos]) + 1,
+	}
+}
+
+// UndefinedError lists the names an expression refers to which have no value.
+// It wraps ErrUndefinedName so that it can be matched with errors.Is.
+type UndefinedError struct {
+	// Names holds each undefined name once, in order of first appearance.
+	Names []string
+}
+
+func (e *UndefinedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUndefinedName, strings.Join(e.Names, ", "))
+}
+
+func (e *UndefinedError) Unwrap() error {
+	return ErrUndefinedName
+}
+
+// newUndefinedError returns an UndefinedError for the names referenced by the given nodes.
+func newUndefinedError(refs []*IdentNode) *UndefinedError {
+	seen := make(map[string]bool)
+	err := &UndefinedError{}
+
+	for _, ref := range refs {
+		if !seen[ref.Name] {
+			seen[ref.Name] = true
+			err.Names = append(err.Names, ref.Name)
+		}
+	}
+
+	return err
+}
+
+// newUndefinedEvalError returns an EvalError wrapping an UndefinedError for the names referenced by refs.
+// It locates the first reference.
+func newUndefinedEvalError(expr string, refs []*IdentNode) *EvalError {
+	undefinedErr := newUndefinedError(refs)
+	err := newEvalError(expr, undefinedErr, refs[0])
+	// the list of names is more useful than the text of the first one
+	err.Msg = undefinedErr.Error()
+	return err
+}
+
+// newCallEvalError returns an EvalError locating a call of a user-defined function
+// which failed because of bodyErr, the error in the function's body.
+func newCallEvalError(expr string, bodyErr *EvalError, node *CallNode) *EvalError {
+	if bodyErr.Err == ErrCallDepthExceeded {
+		// report runaway recursion once at the outermost call rather than at every level
+		return newEvalError(expr, ErrCallDepthExceeded, node)
+	}
+
+	err := newEvalError(expr, bodyErr, node)
+	err.Msg = fmt.Sprintf("%s in %s", bodyErr.Msg, expr[node.Pos():node.End()])
+	return err
+}
+
+// describeLocation appends the location of a problem to its description.
+func describeLocation(msg string, column, statement int) string {
+	if statement > 0 {
+		return fmt.Sprintf("%s in statement %d at column %d", msg, statement, column)
+	}
+	return fmt.Sprintf("%s at column %d", msg, column)
+}
+
+// newSyntaxError returns a SyntaxError for the text between the start and end offsets of expr.
+func newSyntaxError(expr string, err error, start, end int) *SyntaxError {
+	msg := err.Error()
+	switch {
+	case err == ErrIllegalStart && end == start:
+		msg = "expression is empty"
+	case err == ErrIllegalStart, err == ErrIllegalEnd:
+		// the message reads on into the offending token, as in `expression cannot begin with "*"`
+		msg = fmt.Sprintf("%s %q", msg, expr[start:end])
+	case end > start:
+		msg = fmt.Sprintf("%s: %q", msg, expr[start:end])
+	}
+
+	return &SyntaxError{
+		Err:    err,
+		Msg:    msg,
+		Offset: start,
+		Length: end - start,
+		Column: utf8.RuneCountInString(expr[:start]) + 1,
+	}
+}
@@ -0,0 +1,198 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+)
+
+// undefinedNames returns the references in the tree to names which neither env, the constants,
+// a preceding assignment nor the parameters of an enclosing function definition define.
+func undefinedNames(node Node, env *Env) []*IdentNode {
+	var undefined []*IdentNode
+	assigned := make(map[string]bool)
+
+	statements := []Node{node}
+	if program, ok := node.(*ProgramNode); ok {
+		statements = program.Statements
+	}
+
+	for _, statement := range statements {
+		// the parameters of a function are only defined in its body
+		var params map[string]bool
+		if def, ok := statement.(*FuncDefNode); ok {
+			params = make(map[string]bool)
+			for _, param := range def.Params {
+				params[param] = true
+			}
+		}
+
+		walk(statement, func(node Node) {
+			ident, ok := node.(*IdentNode)
+			if !ok || assigned[ident.Name] || params[ident.Name] {
+				return
+			}
+
+			if _, ok := env.lookup(ident.Name); !ok {
+				undefined = append(undefined, ident)
+			}
+		})
+
+		// the name is only defined for the statements following the assignment
+		if assignment, ok := statement.(*AssignNode); ok {
+			assigned[assignment.Name] = true
+		}
+	}
+
+	return undefined
+}
+
+// locateEvalStatement records which statement of a program an evaluation error occurred in.
+func locateEvalStatement(err *EvalError, node Node) *EvalError {
+	program, ok := node.(*ProgramNode)
+	if !ok {
+		return err
+	}
+
+	for i, statement := range program.Statements {
+		if err.Offset >= statement.Pos() && err.Offset < statement.End() {
+			err.Statement = i + 1
+		}
+	}
+	return err
+}
+
+// resolveFunction returns the function called by node along with its definition if it is user-defined.
+// User-defined functions in env take precedence over library functions of the same name.
+// An error is returned if neither exists or the function doesn't accept the number of arguments given.
+func resolveFunction(expr string, node *CallNode, env *Env) (function, *userFunction, error) {
+	userFn, isUserFn := env.lookupFunction(node.Name)
+	fn, isLibraryFn := functions[node.Name]
+
+	switch {
+	case isUserFn:
+		fn = function{minArgs: len(userFn.params), maxArgs: len(userFn.params)}
+	case !isLibraryFn:
+		return function{}, nil, newEvalError(expr, ErrUnknownFunction, node)
+	}
+
+	if !fn.accepts(len(node.Args)) {
+		err := fmt.Errorf("%w: %s expects %s", ErrArgumentCount, node.Name, fn.arity())
+		return function{}, nil, newEvalError(expr, err, node)
+	}
+	return fn, userFn, nil
+}
+
+// callLibraryFunction applies a library function to its arguments.
+// Results which can't be represented as a finite number are reported as errors.
+func callLibraryFunction(expr string, node *CallNode, fn function, args []float64) (float64, error) {
+	result, err := fn.call(args)
+	if err == nil {
+		err = checkFinite(result, ErrDomain, args...)
+	}
+	if err != nil {
+		return 0, newEvalError(expr, err, node)
+	}
+	return result, nil
+}
+
+// factorialOf computes the factorial of a value followed by the factorial sign, as the factorial function does.
+func factorialOf(operand value) (value, error) {
+	if operand.ref != nil {
+		return value{}, &TypeError{Op: factorialName, Left: operand.typ()}
+	}
+
+	result, err := functions[factorialName].call([]float64{operand.num})
+	if err == nil {
+		err = checkFinite(result, ErrDomain, operand.num)
+	}
+	if err != nil {
+		return value{}, err
+	}
+	return number(result), nil
+}
+
+// callMatrixFunction applies a library function of vectors and matrices to its arguments,
+// whose numbers are computed with the arithmetic of s.
+func callMatrixFunction(expr string, node *CallNode, fn function, s scalars, args []Value) (Value, error) {
+	result, err := fn.matrix(s, args)
+	if err != nil {
+		return nil, newEvalError(expr, err, node)
+	}
+	return result, nil
+}
+
+// operate applies a binary operator to its operands.
+// Results which can't be represented as a finite number are reported as errors.
+func operate(op string, left, right float64) (float64, error) {
+	var result float64
+
+	switch op {
+	case add:
+		result = left + right
+	case subtract:
+		result = left - right
+	case multiply, multiplyElements:
+		result = left * right
+	case divide, floorDivide, modulus:
+		if right == 0 {
+			return 0, ErrDivisionByZero
+		}
+
+		switch op {
+		case divide:
+			result = left / right
+		case floorDivide:
+			result = math.Floor(left / right)
+		default:
+			// the result takes the sign of the dividend
+			result = math.Mod(left, right)
+		}
+	case power:
+		if err := checkPower(left == 0, right < 0); err != nil {
+			return 0, err
+		}
+		result = math.Pow(left, right)
+	case addPercent, subtractPercent, multiplyPercent, percentOf:
+		part := left * right / 100
+		switch op {
+		case addPercent:
+			result = left + part
+		case subtractPercent:
+			result = left - part
+		default:
+			result = part
+		}
+	case dividePercent, asPercentOf:
+		if right == 0 {
+			return 0, ErrDivisionByZero
+		}
+		result = left * 100 / right
+	default:
+		return 0, unsupportedOperator(op)
+	}
+
+	if err := checkFinite(result, ErrNotANumber, left, right); err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+// checkFinite returns nanErr if result is NaN, ErrOverflow if it is infinite
+// despite being computed from finite operands, and nil otherwise.
+func checkFinite(result float64, nanErr error, operands ...float64) error {
+	if math.IsNaN(result) {
+		return nanErr
+	}
+
+	if math.IsInf(result, 0) {
+		for _, operand := range operands {
+			if math.IsInf(operand, 0) {
+				// infinity was part of the expression rather than the result of an overflow
+				return nil
+			}
+		}
+		return ErrOverflow
+	}
+
+	return nil
+}
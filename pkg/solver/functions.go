@@ -0,0 +1,288 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// function is an operation which can be called by name from an expression.
+type function struct {
+	// minArgs and maxArgs bound the number of arguments accepted.
+	// maxArgs is -1 if any number of arguments above minArgs is accepted.
+	minArgs int
+	maxArgs int
+	// aggregate reports whether lists passed to the function are replaced by their elements,
+	// so that sum([1, 2], 3) is sum(1, 2, 3).
+	aggregate bool
+	// scalarLast reports whether the last argument of an aggregate function is a number which is never replaced,
+	// as the percentage of percentile is.
+	scalarLast bool
+	call       func(args []float64) (float64, error)
+	// matrix is called instead of call by the functions of vectors and matrices, with their arguments as they are
+	// and the arithmetic of the numbers in them.
+	matrix func(s scalars, args []Value) (Value, error)
+}
+
+// factorialName is the name of the function which computes n!.
+const factorialName = "factorial"
+
+// functions is the library of functions available to every expression.
+var functions = map[string]function{
+	// powers and logarithms
+	"sqrt":  unaryFunction(math.Sqrt, nonNegative),
+	"cbrt":  unaryFunction(math.Cbrt, nil),
+	"exp":   unaryFunction(math.Exp, nil),
+	"ln":    unaryFunction(math.Log, positive),
+	"log2":  unaryFunction(math.Log2, positive),
+	"log10": unaryFunction(math.Log10, positive),
+	"log":   {minArgs: 1, maxArgs: 2, call: logarithm},
+	"pow":   binaryFunction(math.Pow),
+	"hypot": binaryFunction(math.Hypot),
+
+	// trigonometry
+	"sin":   unaryFunction(math.Sin, nil),
+	"cos":   unaryFunction(math.Cos, nil),
+	"tan":   unaryFunction(math.Tan, nil),
+	"asin":  unaryFunction(math.Asin, unitInterval),
+	"acos":  unaryFunction(math.Acos, unitInterval),
+	"atan":  unaryFunction(math.Atan, nil),
+	"atan2": binaryFunction(math.Atan2),
+	"sinh":  unaryFunction(math.Sinh, nil),
+	"cosh":  unaryFunction(math.Cosh, nil),
+	"tanh":  unaryFunction(math.Tanh, nil),
+
+	// rounding and magnitude
+	"abs":   unaryFunction(math.Abs, nil),
+	"floor": unaryFunction(math.Floor, nil),
+	"ceil":  unaryFunction(math.Ceil, nil),
+	"round": unaryFunction(math.Round, nil),
+	"trunc": unaryFunction(math.Trunc, nil),
+	"min":   {minArgs: 1, maxArgs: -1, aggregate: true, call: minimum},
+	"max":   {minArgs: 1, maxArgs: -1, aggregate: true, call: maximum},
+
+	// statistics of numbers or lists of numbers
+	"sum":     {minArgs: 1, maxArgs: -1, aggregate: true, call: sum},
+	"product": {minArgs: 1, maxArgs: -1, aggregate: true, call: product},
+	"count":   {minArgs: 1, maxArgs: -1, aggregate: true, call: count},
+	"avg":     {minArgs: 1, maxArgs: -1, aggregate: true, call: mean},
+	"median":  {minArgs: 1, maxArgs: -1, aggregate: true, call: medianOf},
+	"stddev":  {minArgs: 1, maxArgs: -1, aggregate: true, call: standardDeviation},
+	// the last argument of percentile is the percentage, as in percentile(xs, 90)
+	"percentile": {minArgs: 2, maxArgs: -1, aggregate: true, scalarLast: true, call: percentile},
+
+	// probability distributions, with the names and arguments of MATLAB's
+	"erf":      unaryFunction(math.Erf, nil),
+	"normpdf":  {minArgs: 1, maxArgs: 3, call: normalPDF},
+	"normcdf":  {minArgs: 1, maxArgs: 3, call: normalCDF},
+	"norminv":  {minArgs: 1, maxArgs: 3, call: normalInverse},
+	"binopdf":  {minArgs: 3, maxArgs: 3, call: binomialPDF},
+	"binocdf":  {minArgs: 3, maxArgs: 3, call: binomialCDF},
+	"poisspdf": {minArgs: 2, maxArgs: 2, call: poissonPDF},
+	"poisscdf": {minArgs: 2, maxArgs: 2, call: poissonCDF},
+
+	// vectors and matrices
+	"transpose": matrixFunction("transpose", transposed),
+	"det":       matrixFunction("det", determinant),
+	"inv":       matrixFunction("inv", inverse),
+	"solve":     {minArgs: 2, maxArgs: 2, matrix: solveLinear},
+
+	// complex numbers, whose real counterparts are trivial
+	"arg":  unaryFunction(argument, nil),
+	"conj": unaryFunction(identity, nil),
+	"re":   unaryFunction(identity, nil),
+	"im":   unaryFunction(func(float64) float64 { return 0 }, nil),
+
+	// combinatorics
+	"factorial": unaryFunction(factorial, naturalNumber),
+	"nCr":       {minArgs: 2, maxArgs: 2, call: combinations},
+	"nPr":       {minArgs: 2, maxArgs: 2, call: permutations},
+	"gamma":     {minArgs: 1, maxArgs: 1, call: gamma},
+}
+
+// unaryFunction wraps a function of a single argument.
+// If domain isn't nil, arguments it rejects are reported as ErrDomain.
+func unaryFunction(f func(float64) float64, domain func(float64) bool) function {
+	return function{minArgs: 1, maxArgs: 1, call: func(args []float64) (float64, error) {
+		if domain != nil && !domain(args[0]) {
+			return 0, ErrDomain
+		}
+		return f(args[0]), nil
+	}}
+}
+
+// binaryFunction wraps a function of two arguments.
+func binaryFunction(f func(float64, float64) float64) function {
+	return function{minArgs: 2, maxArgs: 2, call: func(args []float64) (float64, error) {
+		return f(args[0], args[1]), nil
+	}}
+}
+
+// spreads reports whether the argument at index i of n is replaced by its elements if it is a list.
+func (f function) spreads(i, n int) bool {
+	return f.aggregate && !(f.scalarLast && i == n-1)
+}
+
+// arity returns a description of the number of arguments the function accepts.
+func (f function) arity() string {
+	switch {
+	case f.maxArgs == -1 && f.minArgs == 1:
+		return "at least 1 argument"
+	case f.maxArgs == -1:
+		return fmt.Sprintf("at least %d arguments", f.minArgs)
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+// accepts reports whether the function can be called with count arguments.
+func (f function) accepts(count int) bool {
+	return count >= f.minArgs && (f.maxArgs == -1 || count <= f.maxArgs)
+}
+
+func nonNegative(x float64) bool {
+	return x >= 0
+}
+
+func positive(x float64) bool {
+	return x > 0
+}
+
+func unitInterval(x float64) bool {
+	return x >= -1 && x <= 1
+}
+
+func naturalNumber(x float64) bool {
+	return x >= 0 && x == math.Trunc(x)
+}
+
+// argument returns the angle of a real number in the complex plane, which is pi for negative numbers.
+func argument(x float64) float64 {
+	if x < 0 {
+		return math.Pi
+	}
+	return 0
+}
+
+func identity(x float64) float64 {
+	return x
+}
+
+// factorial returns the product of the integers from 1 to n,
+// which overflows float64 beyond 170.
+func factorial(n float64) float64 {
+	result := 1.0
+	for i := 2.0; i <= n && !math.IsInf(result, 1); i++ {
+		result *= i
+	}
+	return result
+}
+
+// logarithm returns the logarithm of its first argument in the base given by the second,
+// or in base 10 if there is no second argument.
+func logarithm(args []float64) (float64, error) {
+	if !positive(args[0]) {
+		return 0, ErrDomain
+	}
+
+	if len(args) == 1 {
+		return math.Log10(args[0]), nil
+	}
+
+	base := args[1]
+	if !positive(base) || base == 1 {
+		return 0, ErrDomain
+	}
+	return math.Log(args[0]) / math.Log(base), nil
+}
+
+// minimum and maximum fail with ErrDomain if they are only given empty lists.
+func minimum(args []float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, ErrDomain
+	}
+
+	result := args[0]
+	for _, arg := range args[1:] {
+		result = math.Min(result, arg)
+	}
+	return result, nil
+}
+
+func maximum(args []float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, ErrDomain
+	}
+
+	result := args[0]
+	for _, arg := range args[1:] {
+		result = math.Max(result, arg)
+	}
+	return result, nil
+}
+
+func sum(args []float64) (float64, error) {
+	result := 0.0
+	for _, arg := range args {
+		result += arg
+	}
+	return result, nil
+}
+
+func product(args []float64) (float64, error) {
+	result := 1.0
+	for _, arg := range args {
+		result *= arg
+	}
+	return result, nil
+}
+
+func count(args []float64) (float64, error) {
+	return float64(len(args)), nil
+}
+
+// mean returns the arithmetic mean of its arguments, and fails with ErrDomain if there are none.
+func mean(args []float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, ErrDomain
+	}
+
+	total, _ := sum(args)
+	return total / float64(len(args)), nil
+}
+
+// medianOf returns the middle one of its arguments once sorted, or the mean of the two middle ones
+// if there is an even number of them, and fails with ErrDomain if there are none.
+func medianOf(args []float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, ErrDomain
+	}
+
+	sorted := append([]float64(nil), args...)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle], nil
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2, nil
+}
+
+// standardDeviation returns the standard deviation of a sample, which divides by one less than
+// the number of arguments, and fails with ErrDomain if there are fewer than two.
+func standardDeviation(args []float64) (float64, error) {
+	if len(args) < 2 {
+		return 0, ErrDomain
+	}
+
+	average, _ := mean(args)
+	squares := 0.0
+	for _, arg := range args {
+		squares += (arg - average) * (arg - average)
+	}
+	return math.Sqrt(squares / float64(len(args)-1)), nil
+}
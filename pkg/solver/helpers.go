@@ -9,18 +9,137 @@ const (
 	add              = "+"
 	subtract         = "-"
 	multiply         = "*"
+	multiplyElements = ".*"
 	divide           = "/"
+	floorDivide      = "//"
+	modulus          = "%"
+	power            = "^"
+	powerAlias       = "**"
+	bitAnd           = "&"
+	bitOr            = "|"
+	bitXor           = "xor"
+	bitNot           = "~"
+	shiftLeft        = "<<"
+	shiftRight       = ">>"
+	convertTo        = "to"
+	convertIn        = "in"
+	percent          = "%"
+	factorialSign    = "!"
+	percentOf        = "of"
+	asPercent        = "as"
+	asPercentOf      = "as % of"
+	less             = "<"
+	lessOrEqual      = "<="
+	equal            = "=="
+	notEqual         = "!="
+	greaterOrEqual   = ">="
+	greater          = ">"
+	and              = "&&"
+	or               = "||"
+	not              = "!"
+	question         = "?"
+	colon            = ":"
+	listRange        = ".."
+	openBracket      = "["
+	closeBracket     = "]"
 	decimal          = "."
+	comma            = ","
+	assign           = "="
+	semicolon        = ";"
 	whitespace       = " "
 )
 
+// quote delimits string literals.
+const quote = '"'
+
 // errors
 var (
 	ErrMalformedExp               = errors.New("malformed expression")
 	ErrIllegalCharacter           = errors.New("illegal character detected")
+	ErrMalformedNumber            = errors.New("malformed number")
+	ErrInvalidName                = errors.New("invalid name")
 	ErrDepthExceeded              = errors.New("maximum parenthesis depth exceeded")
-	ErrIllegalStart               = errors.New("expressions must begin only with a digit, '(' or '-'")
-	ErrIllegalEnd                 = errors.New("expressions must end only with a digit or ')'")
+	ErrIllegalStart               = errors.New("expression cannot begin with")
+	ErrIllegalEnd                 = errors.New("expression cannot end with")
 	ErrEmptyParentheses           = errors.New("empty parentheses are not allowed")
 	ErrIllegalConsecutiveOperator = errors.New("illegal consecutive operators detected")
+	ErrDivisionByZero             = errors.New("division by zero")
+	ErrOverflow                   = errors.New("number is too large to represent")
+	ErrNotANumber                 = errors.New("result is not a number")
+	ErrUnknownFunction            = errors.New("unknown function")
+	ErrUndefinedName              = errors.New("undefined name")
+	ErrCallDepthExceeded          = errors.New("maximum function call depth exceeded")
+	ErrArgumentCount              = errors.New("wrong number of arguments")
+	ErrDomain                     = errors.New("argument is outside the function's domain")
+	ErrNotRational                = errors.New("result is not a rational number")
+	ErrIntegerOperator            = errors.New("operator is only allowed in integer mode")
+	ErrNotInteger                 = errors.New("result is not an integer")
+	ErrNegativeShift              = errors.New("negative shift count")
+	ErrBase                       = errors.New("base must be between 2 and 36")
+	ErrNotReal                    = errors.New("operand must be a real number")
+	ErrUnitOperator               = errors.New("operator is only allowed in units or currency mode")
+	ErrDimension                  = errors.New("incompatible dimensions")
+	ErrCurrencySymbol             = errors.New("currency symbols are only allowed in currency mode")
+	ErrCurrency                   = errors.New("incompatible currencies")
+	ErrMoneyProduct               = errors.New("cannot multiply two amounts of money")
+	ErrUnknownCurrency            = errors.New("unknown currency")
+	ErrPercentOf                  = errors.New("'of' must follow a percentage")
+	ErrType                       = errors.New("mismatched types")
+	ErrMissingColon               = errors.New("'?' must be followed by ':'")
+	ErrUnterminatedString         = errors.New("unterminated string")
+	ErrIndex                      = errors.New("index must be an integer within the list")
+	ErrListLength                 = errors.New("list is too long")
+	ErrShape                      = errors.New("incompatible matrix sizes")
+	ErrSingular                   = errors.New("matrix is singular")
+)
+
+// names of the boolean literals and of the conditional function, which can't be used as other names
+const (
+	trueName  = "true"
+	falseName = "false"
+	ifName    = "if"
 )
+
+// keywords are the names which are part of the grammar rather than variables or functions.
+var keywords = map[string]bool{
+	trueName:  true,
+	falseName: true,
+	ifName:    true,
+}
+
+// operators applying the percentage on their right to their left operand, as in 200 + 10%,
+// which are never written in expressions
+const (
+	addPercent      = "+%"
+	subtractPercent = "-%"
+	multiplyPercent = "*%"
+	dividePercent   = "/%"
+)
+
+// percentOperators map the operators to their counterparts applying a percentage.
+var percentOperators = map[string]string{
+	add:      addPercent,
+	subtract: subtractPercent,
+	multiply: multiplyPercent,
+	divide:   dividePercent,
+}
+
+// modeOperators are the operators which are only allowed in one mode,
+// mapped to the error reported when they are used in another.
+var modeOperators = map[string]error{
+	bitAnd:     ErrIntegerOperator,
+	bitOr:      ErrIntegerOperator,
+	bitXor:     ErrIntegerOperator,
+	bitNot:     ErrIntegerOperator,
+	shiftLeft:  ErrIntegerOperator,
+	shiftRight: ErrIntegerOperator,
+	convertTo:  ErrUnitOperator,
+}
+
+// unsupportedOperator returns the error for an operator which a number system doesn't implement.
+func unsupportedOperator(op string) error {
+	if err, ok := modeOperators[op]; ok {
+		return err
+	}
+	return ErrMalformedExp
+}
@@ -0,0 +1,278 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxSafeInteger is the largest magnitude below which every integer is exactly a float64.
+const maxSafeInteger = 1 << 53
+
+// integerSystem evaluates expressions with integers of any size, which are *big.Int values.
+// Bitwise operators treat negative integers as if they were in two's complement with infinitely many bits.
+type integerSystem struct{}
+
+func (s integerSystem) literal(text string) (interface{}, error) {
+	coef, exp, err := parseDecimal(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp > maxExactBits/4 {
+		// a power of ten has a little over 3 bits per digit
+		return nil, ErrOverflow
+	}
+	if exp >= 0 {
+		return s.check(coef.Mul(coef, pow10(exp)))
+	}
+
+	// literals such as 2.0 or 1.5e1 are still integers
+	quotient, remainder := new(big.Int).QuoRem(coef, pow10(-exp), new(big.Int))
+	if remainder.Sign() != 0 {
+		return nil, ErrNotInteger
+	}
+	return quotient, nil
+}
+
+func (s integerSystem) fromFloat(x float64) (interface{}, error) {
+	switch {
+	case math.IsNaN(x):
+		return nil, ErrNotANumber
+	case math.IsInf(x, 0), x != math.Trunc(x):
+		return nil, ErrNotInteger
+	}
+
+	result, _ := big.NewFloat(x).Int(nil)
+	return result, nil
+}
+
+// constant fails for every constant, since none of them are finite integers.
+func (s integerSystem) constant(name string) (interface{}, error) {
+	return s.fromFloat(constants[name])
+}
+
+func (s integerSystem) owns(x interface{}) bool {
+	_, ok := x.(*big.Int)
+	return ok
+}
+
+func (s integerSystem) unary(op string, x interface{}) (interface{}, error) {
+	switch op {
+	case subtract:
+		return new(big.Int).Neg(x.(*big.Int)), nil
+	case bitNot:
+		return new(big.Int).Not(x.(*big.Int)), nil
+	}
+	return nil, unsupportedOperator(op)
+}
+
+func (s integerSystem) operate(op string, left, right interface{}) (interface{}, error) {
+	l, r := left.(*big.Int), right.(*big.Int)
+
+	switch op {
+	case add:
+		return s.check(new(big.Int).Add(l, r))
+	case subtract:
+		return s.check(new(big.Int).Sub(l, r))
+	case multiply:
+		return s.check(new(big.Int).Mul(l, r))
+	case divide, floorDivide, modulus:
+		if r.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+
+		quotient, remainder := new(big.Int).QuoRem(l, r, new(big.Int))
+		switch op {
+		case divide:
+			if remainder.Sign() != 0 {
+				return nil, ErrNotInteger
+			}
+			return quotient, nil
+		case floorDivide:
+			if remainder.Sign() != 0 && remainder.Sign() != r.Sign() {
+				quotient.Sub(quotient, bigOne)
+			}
+			return quotient, nil
+		default:
+			// the remainder takes the sign of the dividend
+			return remainder, nil
+		}
+	case power:
+		return s.power(l, r)
+	case bitAnd:
+		return new(big.Int).And(l, r), nil
+	case bitOr:
+		return new(big.Int).Or(l, r), nil
+	case bitXor:
+		return new(big.Int).Xor(l, r), nil
+	case shiftLeft, shiftRight:
+		return s.shift(op, l, r)
+	}
+
+	return nil, unsupportedOperator(op)
+}
+
+// power raises base to the power of exp. Negative powers are only integers for bases of 1 and -1.
+func (s integerSystem) power(base, exp *big.Int) (interface{}, error) {
+	if err := checkPower(base.Sign() == 0, exp.Sign() < 0); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case exp.Sign() == 0:
+		return big.NewInt(1), nil
+	case base.Sign() == 0 || base.Cmp(bigOne) == 0:
+		return base, nil
+	case base.CmpAbs(bigOne) == 0:
+		// -1 to an even power is 1
+		if exp.Bit(0) == 0 {
+			return big.NewInt(1), nil
+		}
+		return base, nil
+	case exp.Sign() < 0:
+		return nil, ErrNotInteger
+	}
+
+	if !exactPowerFits(base.BitLen()-1, exp) {
+		return nil, ErrOverflow
+	}
+	return new(big.Int).Exp(base, exp, nil), nil
+}
+
+// shift shifts the bits of x left or right by n places.
+// Shifting right rounds towards negative infinity, as if dividing by a power of 2.
+func (s integerSystem) shift(op string, x, n *big.Int) (interface{}, error) {
+	if n.Sign() < 0 {
+		return nil, ErrNegativeShift
+	}
+
+	if op == shiftLeft {
+		if x.Sign() == 0 {
+			return x, nil
+		}
+		if !n.IsInt64() || int64(x.BitLen())+n.Int64() > maxExactBits {
+			return nil, ErrOverflow
+		}
+		return new(big.Int).Lsh(x, uint(n.Int64())), nil
+	}
+
+	// shifting out every bit leaves 0, or -1 for negative numbers
+	places := uint(x.BitLen() + 1)
+	if n.IsInt64() && n.Int64() < int64(places) {
+		places = uint(n.Int64())
+	}
+	return new(big.Int).Rsh(x, places), nil
+}
+
+func (s integerSystem) compare(op string, left, right interface{}) (bool, error) {
+	return compared(op, left.(*big.Int).Cmp(right.(*big.Int))), nil
+}
+
+func (s integerSystem) call(name string, fn function, args []interface{}) (interface{}, error) {
+	x := args[0].(*big.Int)
+
+	switch name {
+	case "abs":
+		return new(big.Int).Abs(x), nil
+	case "floor", "ceil", "round", "trunc":
+		// integers are already rounded
+		return x, nil
+	case "min", "max":
+		result := x
+		for _, arg := range args[1:] {
+			cmp := arg.(*big.Int).Cmp(result)
+			if name == "min" && cmp < 0 || name == "max" && cmp > 0 {
+				result = arg.(*big.Int)
+			}
+		}
+		return result, nil
+	case "sqrt":
+		if x.Sign() < 0 {
+			return nil, ErrDomain
+		}
+		root := new(big.Int).Sqrt(x)
+		if new(big.Int).Mul(root, root).Cmp(x) != 0 {
+			return nil, ErrNotInteger
+		}
+		return root, nil
+	case "pow":
+		return s.operate(power, x, args[1])
+	case factorialName, "nCr", "nPr":
+		integers := make([]*big.Int, len(args))
+		for i, arg := range args {
+			integers[i] = arg.(*big.Int)
+		}
+		return combinatorial(name, integers)
+	}
+
+	// other functions are computed with float64, whose results are only exact for small integers
+	floats := make([]float64, len(args))
+	for i, arg := range args {
+		floats[i] = s.float(arg)
+	}
+
+	result, err := fn.call(floats)
+	if err == nil {
+		err = checkFinite(result, ErrDomain, floats...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if math.Abs(result) > maxSafeInteger {
+		return nil, ErrNotInteger
+	}
+	return s.fromFloat(result)
+}
+
+// combinatorial computes n!, nCr or nPr exactly from the natural numbers n and r.
+// The number of ways of choosing or arranging more items than there are is 0.
+func combinatorial(name string, args []*big.Int) (*big.Int, error) {
+	n, r := args[0], args[0]
+	if len(args) > 1 {
+		r = args[1]
+	}
+	switch {
+	case n.Sign() < 0 || r.Sign() < 0:
+		return nil, ErrDomain
+	case r.Cmp(n) > 0:
+		return new(big.Int), nil
+	case !n.IsInt64():
+		return nil, ErrOverflow
+	}
+
+	// the result is a product of k factors of at most n, so it has fewer than k × log2(n) bits
+	k := r.Int64()
+	if name == "nCr" && n.Int64()-k < k {
+		k = n.Int64() - k
+	}
+	if float64(k)*math.Log2(float64(n.Int64())) > maxExactBits {
+		return nil, ErrOverflow
+	}
+
+	if name == "nCr" {
+		return new(big.Int).Binomial(n.Int64(), k), nil
+	}
+	return new(big.Int).MulRange(n.Int64()-k+1, n.Int64()), nil
+}
+
+// check returns a result, or ErrOverflow if it has grown too large to keep computing with.
+func (s integerSystem) check(x *big.Int) (interface{}, error) {
+	if x.BitLen() > maxExactBits {
+		return nil, ErrOverflow
+	}
+	return x, nil
+}
+
+func (s integerSystem) float(x interface{}) float64 {
+	f, _ := new(big.Float).SetInt(x.(*big.Int)).Float64()
+	return f
+}
+
+func (s integerSystem) integer(x interface{}) (*big.Int, bool) {
+	return new(big.Int).Set(x.(*big.Int)), true
+}
+
+func (s integerSystem) format(x interface{}) string {
+	return x.(*big.Int).String()
+}
@@ -0,0 +1,174 @@
+package solver
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParse_integerOperators(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"bitwise and", "6 & 3", "(6 & 3)"},
+		{"bitwise precedence", "1 | 2 xor 3 & 4", "(1 | (2 xor (3 & 4)))"},
+		{"shift below addition", "1 << 2 + 3", "(1 << (2 + 3))"},
+		{"shift above bitwise and", "1 << 2 & 3 >> 1", "((1 << 2) & (3 >> 1))"},
+		{"complement", "~x & mask", "((~x) & mask)"},
+		{"negated complement", "-~1", "(-(~1))"},
+		{"complement of power", "~2^2", "(~(2 ^ 2))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression, WithInteger())
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_integerOperatorsOutsideIntegerMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantOffset int
+		wantLength int
+	}{
+		{"bitwise and", "6 & 3", nil, 2, 1},
+		{"word operator", "6 xor 3", nil, 2, 3},
+		{"shift", "1 + 1 << 4", nil, 6, 2},
+		{"complement", "~1", nil, 0, 1},
+		{"decimal mode", "2 | 1", []Option{WithPrecision(10)}, 2, 1},
+		{"rational mode", "x = 1; x >> 1", []Option{WithRational(Fraction, RejectInexact)}, 9, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression, tt.opts...)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !errors.Is(err, ErrIntegerOperator) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, ErrIntegerOperator)
+			}
+			if syntaxErr.Offset != tt.wantOffset || syntaxErr.Length != tt.wantLength {
+				t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", syntaxErr.Offset, syntaxErr.Length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestSolve_integer(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantResult string
+	}{
+		{"large power", "2^200", "1606938044258990275541962092341162602522202993782792835301376"},
+		{"factorial", "factorial(30)", "265252859812191058636308480000000"},
+		{"exact division", "2^100 / 2^98", "4"},
+		{"floor division", "-7 // 2", "-4"},
+		{"modulus", "-7 % 2", "-1"},
+		{"bitwise and", "12 & 10", "8"},
+		{"bitwise or", "12 | 10", "14"},
+		{"bitwise xor", "12 xor 10", "6"},
+		{"complement", "~5", "-6"},
+		{"negative and", "-1 & 255", "255"},
+		{"shift left", "1 << 100", "1267650600228229401496703205376"},
+		{"shift right", "1000 >> 3", "125"},
+		{"negative shift right", "-9 >> 1", "-5"},
+		{"shift everything out", "-9 >> 1000", "-1"},
+		{"integer literal with decimal point", "2.0 * 15.00", "30"},
+		{"prefixed literals", "0xFF & 0b1010 | 0o100", "74"},
+		{"scientific literal", "6.02E23 + 1_000", "602000000000000000001000"},
+		{"prefixed literal beyond float64", "0x1_0000_0000_0000_0000_0000 >> 64", "65536"},
+		{"exact square root", "sqrt(2^100)", "1125899906842624"},
+		{"power of minus one", "(-1)^-3", "-1"},
+		{"rounding is exact", "round(7) + abs(-2) + max(1, 3, 2)", "12"},
+		{"float function with integer result", "log2(1024)", "10"},
+		{"program", "mask = (1 << 8) - 1; 4660 & mask", "52"},
+		{"user-defined function", "bit(x, n) = (x >> n) & 1; bit(5, 0) + bit(5, 1)", "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, WithInteger())
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_integerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+	}{
+		{"fractional literal", "1.5 + 1", ErrNotInteger},
+		{"inexact division", "7 / 2", ErrNotInteger},
+		{"division by zero", "1 // (2 - 2)", ErrDivisionByZero},
+		{"negative power", "2^-1", ErrNotInteger},
+		{"negative power of zero", "0^-1", ErrDivisionByZero},
+		{"constant", "2 * pi", ErrNotInteger},
+		{"inexact square root", "sqrt(2)", ErrNotInteger},
+		{"inexact function", "sin(1)", ErrNotInteger},
+		{"negative factorial", "factorial(-1)", ErrDomain},
+		{"negative shift", "1 << -1", ErrNegativeShift},
+		{"huge shift", "1 << 2^40", ErrOverflow},
+		{"huge power", "3^10^10", ErrOverflow},
+		{"undefined name", "x & 1", ErrUndefinedName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, WithInteger())
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalWithEnv_integer(t *testing.T) {
+	env := NewEnv(map[string]float64{"flags": 6})
+
+	// integers beyond float64 are kept exactly for later evaluations in integer mode
+	result, err := EvalWithEnv("lowbit(x) = x & -x; big = flags << 70", env, WithInteger())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if got, ok := result.Int(); !ok || got.Cmp(new(big.Int).Lsh(big.NewInt(6), 70)) != 0 {
+		t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", got, ok, new(big.Int).Lsh(big.NewInt(6), 70), true)
+	}
+
+	result, err = EvalWithEnv("lowbit(big) >> 70", env, WithInteger())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "2" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "2")
+	}
+
+	// functions using the operators of integer mode can't be called outside of it
+	for _, opts := range [][]Option{nil, {WithPrecision(10)}} {
+		_, err = EvalWithEnv("lowbit(12)", env, opts...)
+		if !errors.Is(err, ErrIntegerOperator) {
+			t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrIntegerOperator)
+		}
+	}
+}
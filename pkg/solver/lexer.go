@@ -0,0 +1,268 @@
+package solver
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind classifies the tokens produced by the lexer.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenOperator
+	tokenIdentifier
+	tokenOpenParen
+	tokenCloseParen
+	tokenComma
+	tokenAssign
+	tokenSemicolon
+	tokenCurrency
+	tokenString
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+// punctuation maps the single characters which aren't operators to their token kinds.
+var punctuation = map[string]tokenKind{
+	openParenthesis:  tokenOpenParen,
+	closeParenthesis: tokenCloseParen,
+	openBracket:      tokenOpenBracket,
+	closeBracket:     tokenCloseBracket,
+	comma:            tokenComma,
+	assign:           tokenAssign,
+	semicolon:        tokenSemicolon,
+}
+
+// token is a single lexical element of an expression.
+// pos is the byte offset of the token in the original expression.
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// end returns the byte offset just past the token.
+func (t token) end() int {
+	return t.pos + len(t.value)
+}
+
+// operators lists every operator the lexer recognises.
+// Longer operators come first so that "**" isn't read as two "*".
+// A decimal point that isn't part of a number is kept as an operator
+// so that the parser can report it in context.
+var operators = []string{
+	powerAlias, floorDivide, shiftLeft, shiftRight, listRange, multiplyElements,
+	lessOrEqual, equal, notEqual, greaterOrEqual, and, or,
+	add, subtract, multiply, divide, modulus, power, decimal,
+	bitAnd, bitOr, bitNot, less, greater, not, question, colon,
+}
+
+// wordOperators are the operators spelled like names, which can't be used as names.
+var wordOperators = map[string]bool{
+	bitXor:    true,
+	convertTo: true,
+	convertIn: true,
+	percentOf: true,
+	asPercent: true,
+}
+
+// tokenize splits an expression into a list of tokens terminated by a tokenEOF.
+// An error is returned alongside the tokens preceding it
+// if a character that cannot start any token is found, or a number is malformed.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	pos := 0
+
+	for pos < len(expr) {
+		char, size := utf8.DecodeRuneInString(expr[pos:])
+
+		switch {
+		case unicode.IsSpace(char):
+			pos += size
+
+		case isDigit(char):
+			start := pos
+			end, err := scanNumber(expr, pos)
+			if err != nil {
+				return tokens, newSyntaxError(expr, err, start, end)
+			}
+			pos = end
+			tokens = append(tokens, token{kind: tokenNumber, value: expr[start:pos], pos: start})
+
+		case isIdentifierStart(char):
+			start := pos
+			pos = scanIdentifier(expr, pos)
+
+			kind := tokenIdentifier
+			if wordOperators[expr[start:pos]] {
+				kind = tokenOperator
+			}
+			tokens = append(tokens, token{kind: kind, value: expr[start:pos], pos: start})
+
+		case char == quote:
+			start := pos
+			pos = scanString(expr, pos)
+			if pos < 0 {
+				return tokens, newSyntaxError(expr, ErrUnterminatedString, start, len(expr))
+			}
+			tokens = append(tokens, token{kind: tokenString, value: expr[start:pos], pos: start})
+
+		case currencySymbols[string(char)] != "":
+			tokens = append(tokens, token{kind: tokenCurrency, value: string(char), pos: pos})
+			pos += size
+
+		case matchOperator(expr[pos:]) != "":
+			op := matchOperator(expr[pos:])
+			tokens = append(tokens, token{kind: tokenOperator, value: op, pos: pos})
+			pos += len(op)
+
+		default:
+			kind, ok := punctuation[string(char)]
+			if !ok {
+				return tokens, newSyntaxError(expr, ErrIllegalCharacter, pos, pos+size)
+			}
+
+			tokens = append(tokens, token{kind: kind, value: string(char), pos: pos})
+			pos += size
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(expr)})
+	return tokens, nil
+}
+
+// matchOperator returns the operator at the start of expr,
+// or an empty string if expr doesn't start with one.
+func matchOperator(expr string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(expr, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// radixPrefixes map the prefixes of hexadecimal, octal and binary literals to their bases.
+var radixPrefixes = map[string]int{
+	"0x": 16, "0X": 16,
+	"0o": 8, "0O": 8,
+	"0b": 2, "0B": 2,
+}
+
+// scanNumber returns the offset just past the number literal starting at pos.
+// Literals are decimals with an optional fraction and exponent, such as 6.02E23,
+// or integers with a 0x, 0o or 0b prefix, such as 0xFF.
+// Digits may be separated by single underscores, as in 1_000_000.
+//
+// A decimal point is only consumed if it is followed by a digit, and an exponent or a prefix
+// only if they are followed by digits, so "2e" is still 2 times e. However "2e-1" is 0.2.
+// An underscore which doesn't separate two digits, as in 1_ or 1__0, fails with ErrMalformedNumber
+// and the offset just past it.
+func scanNumber(expr string, pos int) (int, error) {
+	if pos+2 < len(expr) {
+		if base, ok := radixPrefixes[expr[pos:pos+2]]; ok && isDigitOf(expr[pos+2], base) {
+			return scanDigits(expr, pos+2, base)
+		}
+	}
+
+	pos, err := scanDigits(expr, pos, 10)
+	if err != nil {
+		return pos, err
+	}
+
+	if pos+1 < len(expr) && string(expr[pos]) == decimal && isDigit(rune(expr[pos+1])) {
+		if pos, err = scanDigits(expr, pos+1, 10); err != nil {
+			return pos, err
+		}
+	}
+
+	if pos < len(expr) && (expr[pos] == 'e' || expr[pos] == 'E') {
+		exponent := pos + 1
+		if exponent < len(expr) && (string(expr[exponent]) == add || string(expr[exponent]) == subtract) {
+			exponent++
+		}
+		if exponent < len(expr) && isDigit(rune(expr[exponent])) {
+			return scanDigits(expr, exponent, 10)
+		}
+	}
+
+	return pos, nil
+}
+
+// scanDigits returns the offset just past the digits in the given base starting at pos,
+// including the underscores separating them.
+func scanDigits(expr string, pos, base int) (int, error) {
+	for pos < len(expr) {
+		switch {
+		case isDigitOf(expr[pos], base):
+			pos++
+		case expr[pos] == '_' && pos+1 < len(expr) && isDigitOf(expr[pos+1], base):
+			pos += 2
+		case expr[pos] == '_':
+			return pos + 1, ErrMalformedNumber
+		default:
+			return pos, nil
+		}
+	}
+
+	return pos, nil
+}
+
+// scanString returns the offset just past the string literal starting at pos, or -1 if it isn't terminated.
+// A backslash escapes the character following it, so that "\"" holds a double quote.
+func scanString(expr string, pos int) int {
+	for i := pos + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isName reports whether s is a name which variables and functions can be given,
+// meaning a whole identifier which isn't a keyword or an operator such as xor.
+func isName(s string) bool {
+	char, _ := utf8.DecodeRuneInString(s)
+	return s != "" && isIdentifierStart(char) && scanIdentifier(s, 0) == len(s) && !keywords[s] && !wordOperators[s]
+}
+
+// scanIdentifier returns the offset just past the identifier starting at pos.
+func scanIdentifier(expr string, pos int) int {
+	for pos < len(expr) {
+		char, size := utf8.DecodeRuneInString(expr[pos:])
+		if !isIdentifierStart(char) && !isDigit(char) {
+			break
+		}
+		pos += size
+	}
+
+	return pos
+}
+
+// isIdentifierStart reports whether char can begin a function name.
+func isIdentifierStart(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
+}
+
+func isDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+// isDigitOf reports whether char is a digit in the given base, which is at most 16.
+func isDigitOf(char byte, base int) bool {
+	switch {
+	case char >= '0' && char <= '9':
+		return int(char-'0') < base
+	case char >= 'a' && char <= 'f':
+		return base == 16
+	case char >= 'A' && char <= 'F':
+		return base == 16
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package solver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_tokenize(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantResult []token
+		wantErr    error
+	}{
+		{"empty", "", []token{{tokenEOF, "", 0}}, nil},
+		{"whitespace", "  ", []token{{tokenEOF, "", 2}}, nil},
+		{
+			"operators and operands", "2+3.5",
+			[]token{{tokenNumber, "2", 0}, {tokenOperator, "+", 1}, {tokenNumber, "3.5", 2}, {tokenEOF, "", 5}},
+			nil,
+		},
+		{
+			"parentheses", "2 (4)",
+			[]token{{tokenNumber, "2", 0}, {tokenOpenParen, "(", 2}, {tokenNumber, "4", 3}, {tokenCloseParen, ")", 4}, {tokenEOF, "", 5}},
+			nil,
+		},
+		{
+			"trailing decimal", "3.",
+			[]token{{tokenNumber, "3", 0}, {tokenOperator, ".", 1}, {tokenEOF, "", 2}},
+			nil,
+		},
+		{
+			"double-character operators", "2**3//4",
+			[]token{{tokenNumber, "2", 0}, {tokenOperator, "**", 1}, {tokenNumber, "3", 3}, {tokenOperator, "//", 4}, {tokenNumber, "4", 6}, {tokenEOF, "", 7}},
+			nil,
+		},
+		{
+			"function call", "max(x_1,2)",
+			[]token{{tokenIdentifier, "max", 0}, {tokenOpenParen, "(", 3}, {tokenIdentifier, "x_1", 4}, {tokenComma, ",", 7}, {tokenNumber, "2", 8}, {tokenCloseParen, ")", 9}, {tokenEOF, "", 10}},
+			nil,
+		},
+		{
+			"statements", "x=1;x",
+			[]token{{tokenIdentifier, "x", 0}, {tokenAssign, "=", 1}, {tokenNumber, "1", 2}, {tokenSemicolon, ";", 3}, {tokenIdentifier, "x", 4}, {tokenEOF, "", 5}},
+			nil,
+		},
+		{
+			"comparison and logical operators", "a<=b!=!c||d?e:f",
+			[]token{{tokenIdentifier, "a", 0}, {tokenOperator, "<=", 1}, {tokenIdentifier, "b", 3}, {tokenOperator, "!=", 4}, {tokenOperator, "!", 6}, {tokenIdentifier, "c", 7}, {tokenOperator, "||", 8}, {tokenIdentifier, "d", 10}, {tokenOperator, "?", 11}, {tokenIdentifier, "e", 12}, {tokenOperator, ":", 13}, {tokenIdentifier, "f", 14}, {tokenEOF, "", 15}},
+			nil,
+		},
+		{
+			"bitwise operators", "~1<<2&x xor y",
+			[]token{{tokenOperator, "~", 0}, {tokenNumber, "1", 1}, {tokenOperator, "<<", 2}, {tokenNumber, "2", 4}, {tokenOperator, "&", 5}, {tokenIdentifier, "x", 6}, {tokenOperator, "xor", 8}, {tokenIdentifier, "y", 12}, {tokenEOF, "", 13}},
+			nil,
+		},
+		{
+			"prefixed literals", "0xFF+0o17-0B1010",
+			[]token{{tokenNumber, "0xFF", 0}, {tokenOperator, "+", 4}, {tokenNumber, "0o17", 5}, {tokenOperator, "-", 9}, {tokenNumber, "0B1010", 10}, {tokenEOF, "", 16}},
+			nil,
+		},
+		{
+			"scientific notation", "1.5e-3*6.02E23",
+			[]token{{tokenNumber, "1.5e-3", 0}, {tokenOperator, "*", 6}, {tokenNumber, "6.02E23", 7}, {tokenEOF, "", 14}},
+			nil,
+		},
+		{
+			"digit separators", "1_000_000.000_1",
+			[]token{{tokenNumber, "1_000_000.000_1", 0}, {tokenEOF, "", 15}},
+			nil,
+		},
+		{
+			"incomplete exponent", "2e+x",
+			[]token{{tokenNumber, "2", 0}, {tokenIdentifier, "e", 1}, {tokenOperator, "+", 2}, {tokenIdentifier, "x", 3}, {tokenEOF, "", 4}},
+			nil,
+		},
+		{
+			"incomplete prefix", "0xg",
+			[]token{{tokenNumber, "0", 0}, {tokenIdentifier, "xg", 1}, {tokenEOF, "", 3}},
+			nil,
+		},
+		{
+			"strings", `"a+b"+"\"("`,
+			[]token{{tokenString, `"a+b"`, 0}, {tokenOperator, "+", 5}, {tokenString, `"\"("`, 6}, {tokenEOF, "", 11}},
+			nil,
+		},
+		{
+			"lists and ranges", "[1..n][0]",
+			[]token{{tokenOpenBracket, "[", 0}, {tokenNumber, "1", 1}, {tokenOperator, "..", 2}, {tokenIdentifier, "n", 4}, {tokenCloseBracket, "]", 5}, {tokenOpenBracket, "[", 6}, {tokenNumber, "0", 7}, {tokenCloseBracket, "]", 8}, {tokenEOF, "", 9}},
+			nil,
+		},
+		{
+			"element-wise product", "a.*2.5",
+			[]token{{tokenIdentifier, "a", 0}, {tokenOperator, ".*", 1}, {tokenNumber, "2.5", 3}, {tokenEOF, "", 6}},
+			nil,
+		},
+		{"illegal character", "3 + #", []token{{tokenNumber, "3", 0}, {tokenOperator, "+", 2}}, ErrIllegalCharacter},
+		{"trailing separator", "2 * 1_", []token{{tokenNumber, "2", 0}, {tokenOperator, "*", 2}}, ErrMalformedNumber},
+		{"doubled separator", "1__0", nil, ErrMalformedNumber},
+		{"separator before fraction", "1_.5", nil, ErrMalformedNumber},
+		{"unterminated string", `1 + "a\"`, []token{{tokenNumber, "1", 0}, {tokenOperator, "+", 2}}, ErrUnterminatedString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tokenize(tt.expression)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(result, tt.wantResult) {
+				t.Errorf("\nGot:\t%#v\nWant:\t%#v", result, tt.wantResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package solver
+
+import "math"
+
+// indexing names the indexing of a list in type errors, such as "cannot index number".
+const indexing = "[]"
+
+// maxListLength bounds the length of the lists created by ranges,
+// so that a mistyped bound such as 1..1e12 fails instead of exhausting memory.
+const maxListLength = 1 << 20
+
+// systemNumber is a number of a number system held in a list, which keeps its exact value.
+type systemNumber struct {
+	system numberSystem
+	x      interface{}
+}
+
+func (n systemNumber) Type() Type     { return NumberType }
+func (n systemNumber) String() string { return n.system.format(n.x) }
+
+// newList returns the list of values computed by the virtual machine.
+func newList(elements []value) value {
+	list := make(List, len(elements))
+	for i, element := range elements {
+		list[i] = element.public()
+	}
+	return value{ref: list}
+}
+
+// rangeList returns the numbers from start up to end in steps of 1, which is empty if start is greater than end.
+// Bounds which aren't finite fail with ErrDomain, and bounds too large for a step of 1 to change them with ErrOverflow.
+func rangeList(start, end float64) (List, error) {
+	if math.IsInf(start, 0) || math.IsNaN(start) || math.IsInf(end, 0) || math.IsNaN(end) {
+		return nil, ErrDomain
+	}
+
+	if end < start {
+		return List{}, nil
+	}
+	length := math.Floor(end-start) + 1
+	if length > maxListLength {
+		return nil, ErrListLength
+	}
+
+	list := make(List, int(length))
+	x := start
+	for i := range list {
+		if i > 0 {
+			if x+1 == x {
+				// the step is lost to rounding, as above 2^53
+				return nil, ErrOverflow
+			}
+			x++
+		}
+		list[i] = Number(x)
+	}
+	return list, nil
+}
+
+// rangeIn returns the numbers of a system from start up to end in steps of 1, as rangeList does.
+// The length of the range is worked out in the system, whose numbers may be too large for a float64.
+func rangeIn(system numberSystem, start, end interface{}) (List, error) {
+	one, err := system.literal("1")
+	if err != nil {
+		return nil, err
+	}
+
+	tooLong, err := exceedsListLength(system, start, end)
+	switch {
+	case err != nil:
+		return nil, err
+	case tooLong:
+		return nil, ErrListLength
+	}
+
+	list := List{}
+	for x := start; ; {
+		past, err := system.compare(greater, x, end)
+		switch {
+		case err != nil:
+			return nil, err
+		case past:
+			return list, nil
+		}
+		list = append(list, systemNumber{system: system, x: x})
+
+		last, err := system.compare(equal, x, end)
+		switch {
+		case err != nil:
+			return nil, err
+		case last:
+			return list, nil
+		}
+
+		next, err := system.operate(add, x, one)
+		if err != nil {
+			return nil, err
+		}
+		stuck, err := system.compare(equal, next, x)
+		switch {
+		case err != nil:
+			return nil, err
+		case stuck:
+			// the step was lost to rounding, as beyond the precision of a decimal
+			return nil, ErrOverflow
+		}
+		x = next
+	}
+}
+
+// exceedsListLength reports whether the range from start to end in a system would hold more than maxListLength numbers.
+func exceedsListLength(system numberSystem, start, end interface{}) (bool, error) {
+	length, err := system.operate(subtract, end, start)
+	if err != nil {
+		return false, err
+	}
+
+	// the limit is added to a zero of the same kind as start, such as an amount of money of its currency,
+	// so that the two can be compared
+	zero, err := system.operate(subtract, start, start)
+	if err != nil {
+		return false, err
+	}
+	limit, err := system.fromFloat(maxListLength)
+	if err != nil {
+		return false, err
+	}
+	if limit, err = system.operate(add, zero, limit); err != nil {
+		return false, err
+	}
+
+	return system.compare(greaterOrEqual, length, limit)
+}
+
+// elementAt returns the element of a list at an index, counting from the end if the index is negative.
+func elementAt(list List, index float64) (Value, error) {
+	if index < 0 {
+		index += float64(len(list))
+	}
+	if index != math.Trunc(index) || index < 0 || index >= float64(len(list)) {
+		return nil, ErrIndex
+	}
+	return list[int(index)], nil
+}
+
+// indexValue returns the element of a list computed by the virtual machine at an index.
+func indexValue(operand, index value) (value, error) {
+	list, ok := operand.ref.(List)
+	switch {
+	case !ok:
+		return value{}, &TypeError{Op: indexing, Left: operand.typ()}
+	case index.ref != nil:
+		return value{}, &TypeError{Op: indexing, Left: ListType, Right: index.typ()}
+	}
+
+	element, err := elementAt(list, index.num)
+	if err != nil {
+		return value{}, err
+	}
+	return fromValue(element), nil
+}
+
+// appendArguments appends the arguments of a call to a library function to floats.
+// Lists given to aggregate functions are replaced by their elements, except for any which must be a number,
+// and any other value which isn't a number is reported with a TypeError.
+func appendArguments(floats []float64, name string, fn function, args []value) ([]float64, error) {
+	for i, arg := range args {
+		list, isList := arg.ref.(List)
+		switch {
+		case arg.ref == nil:
+			floats = append(floats, arg.num)
+		case isList && fn.spreads(i, len(args)):
+			for _, element := range list {
+				if element.Type() != NumberType {
+					return nil, &TypeError{Op: name, Left: element.Type()}
+				}
+				floats = append(floats, approximate(element))
+			}
+		default:
+			return nil, &TypeError{Op: name, Left: arg.typ()}
+		}
+	}
+	return floats, nil
+}
+
+// publicValue returns a Value with the numbers of number systems it holds approximated as Numbers.
+func publicValue(v Value) Value {
+	switch v := v.(type) {
+	case systemNumber:
+		return Number(approximate(v))
+	case List:
+		list := make(List, len(v))
+		for i, element := range v {
+			list[i] = publicValue(element)
+		}
+		return list
+	}
+	return v
+}
+
+// aggregate applies one of the statistical functions other than min and max to numbers of a system
+// with the system's own arithmetic, so that the sum or average of exact numbers is exact.
+// The standard deviation is that of a sample, and its square root is computed by the system's sqrt.
+func aggregate(system numberSystem, name string, args []interface{}) (interface{}, error) {
+	count, err := system.fromFloat(float64(len(args)))
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "count":
+		return count, nil
+	case "sum":
+		return fold(system, add, "0", args)
+	case "product":
+		return fold(system, multiply, "1", args)
+	}
+
+	switch {
+	case len(args) == 0, name == "stddev" && len(args) == 1:
+		return nil, ErrDomain
+	case name == "median":
+		return median(system, args)
+	case name == "percentile":
+		return percentileIn(system, args)
+	}
+
+	mean, err := fold(system, add, "0", args)
+	if err == nil {
+		mean, err = system.operate(divide, mean, count)
+	}
+	if err != nil || name == "avg" {
+		return mean, err
+	}
+
+	squares := make([]interface{}, len(args))
+	for i, arg := range args {
+		deviation, err := system.operate(subtract, arg, mean)
+		if err == nil {
+			squares[i], err = system.operate(multiply, deviation, deviation)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	variance, err := fold(system, add, "0", squares)
+	if err != nil {
+		return nil, err
+	}
+	degrees, err := system.fromFloat(float64(len(args) - 1))
+	if err == nil {
+		variance, err = system.operate(divide, variance, degrees)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return system.call("sqrt", functions["sqrt"], []interface{}{variance})
+}
+
+// fold combines numbers of a system with an operator from left to right, and returns the literal empty if there are none.
+// The first number starts the fold rather than empty, so that sums of quantities keep their units.
+func fold(system numberSystem, op, empty string, args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return system.literal(empty)
+	}
+
+	result := args[0]
+	for _, arg := range args[1:] {
+		var err error
+		if result, err = system.operate(op, result, arg); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// median returns the middle one of numbers of a system, or the mean of the two middle ones if there is an even number of them.
+func median(system numberSystem, args []interface{}) (interface{}, error) {
+	sorted, err := sortNumbers(system, args)
+	if err != nil {
+		return nil, err
+	}
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle], nil
+	}
+
+	total, err := system.operate(add, sorted[middle-1], sorted[middle])
+	if err != nil {
+		return nil, err
+	}
+	two, err := system.literal("2")
+	if err != nil {
+		return nil, err
+	}
+	return system.operate(divide, total, two)
+}
+
+// percentileIn returns the pth percentile of numbers of a system, where p is the last of args,
+// interpolating between the two nearest numbers as percentile does.
+func percentileIn(system numberSystem, args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, ErrDomain
+	}
+
+	p := args[len(args)-1]
+	zero, err := system.literal("0")
+	if err != nil {
+		return nil, err
+	}
+	hundred, err := system.literal("100")
+	if err != nil {
+		return nil, err
+	}
+	below, err := system.compare(less, p, zero)
+	if err != nil {
+		return nil, err
+	}
+	above, err := system.compare(greater, p, hundred)
+	if err != nil {
+		return nil, err
+	}
+	if below || above {
+		return nil, ErrDomain
+	}
+
+	sorted, err := sortNumbers(system, args[:len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	// the rank of the percentile among the sorted numbers, and the fraction of the way to the next one
+	last, err := system.fromFloat(float64(len(sorted) - 1))
+	if err != nil {
+		return nil, err
+	}
+	rank, err := system.operate(multiply, p, last)
+	if err == nil {
+		rank, err = system.operate(divide, rank, hundred)
+	}
+	if err != nil {
+		return nil, err
+	}
+	lower, err := system.call("floor", functions["floor"], []interface{}{rank})
+	if err != nil {
+		return nil, err
+	}
+	index, _ := system.integer(lower)
+	i := int(index.Int64())
+	if i == len(sorted)-1 {
+		return sorted[i], nil
+	}
+
+	fraction, err := system.operate(subtract, rank, lower)
+	if err != nil {
+		return nil, err
+	}
+	gap, err := system.operate(subtract, sorted[i+1], sorted[i])
+	if err == nil {
+		gap, err = system.operate(multiply, fraction, gap)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return system.operate(add, sorted[i], gap)
+}
+
+// sortNumbers returns numbers of a system in increasing order.
+func sortNumbers(system numberSystem, args []interface{}) ([]interface{}, error) {
+	sorted := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		// insertion sort, as the system's comparison can fail
+		i := len(sorted)
+		for ; i > 0; i-- {
+			before, err := system.compare(less, arg, sorted[i-1])
+			if err != nil {
+				return nil, err
+			}
+			if !before {
+				break
+			}
+		}
+		sorted = append(sorted, nil)
+		copy(sorted[i+1:], sorted[i:])
+		sorted[i] = arg
+	}
+	return sorted, nil
+}
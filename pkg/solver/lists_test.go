@@ -0,0 +1,206 @@
+package solver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse_lists(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"list", "[1, 2 + x]", "[1, (2 + x)]"},
+		{"empty list", "[]", "[]"},
+		{"nested lists", "[[1], []]", "[[1], []]"},
+		{"index", "xs[0]", "xs[0]"},
+		{"nested index", "m[1][i - 1]", "m[1][(i - 1)]"},
+		{"index of literal", "[1, 2][-1] * 2", "([1, 2][(-1)] * 2)"},
+		{"index of call", "f(x)[0]", "f(x)[0]"},
+		{"range", "1..n+1", "(1 .. (n + 1))"},
+		{"range in call", "sum(1..10) > 50", "(sum((1 .. 10)) > 50)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_listErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+	}{
+		{"unclosed list", "[1, 2", ErrMalformedExp},
+		{"unclosed index", "xs[1", ErrMalformedExp},
+		{"two indices", "xs[1, 2]", ErrMalformedExp},
+		{"empty index", "xs[]", ErrMalformedExp},
+		{"unopened bracket", "1]", ErrMalformedExp},
+		{"trailing comma", "[1,]", ErrMalformedExp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSolve_lists(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantResult string
+	}{
+		{"list", "[1, 1 + 1, true, \"a\"]", nil, `[1, 2, true, "a"]`},
+		{"index", "xs = [10, 20, 30]; xs[1]", nil, "20"},
+		{"negative index", "xs = [10, 20, 30]; xs[-1]", nil, "30"},
+		{"nested index", "m = [[1, 2], [3, 4]]; m[1][0]", nil, "3"},
+		{"range", "1..5", nil, "[1, 2, 3, 4, 5]"},
+		{"empty range", "3..1", nil, "[]"},
+		{"fractional range", "0.5..3", nil, "[0.5, 1.5, 2.5]"},
+		{"average", "avg([12.5, 14, 9.75]) * 1.2", nil, "14.5"},
+		{"sum of range", "sum(1..100)", nil, "5050"},
+		{"product", "product(1..5)", nil, "120"},
+		{"count", "count([]) + count(1..3)", nil, "3"},
+		{"median", "median([3, 1, 2]) + median([4, 1, 3, 2])", nil, "4.5"},
+		{"standard deviation", "stddev([1, 3, 5])", nil, "2"},
+		{"spread arguments", "max([1, 5], 3, [2])", nil, "5"},
+		{"equality", "[1, [true]] == [1, [1 == 1]]", nil, "true"},
+		{"user-defined function", "last(xs) = xs[-1]; last(1..4)", nil, "4"},
+		{"precision", "sum([0.1, 0.2]) == 0.3", []Option{WithPrecision(34)}, "true"},
+		{"precision average", "avg([12.5, 14, 9.75]) * 1.2", []Option{WithPrecision(34)}, "14.5"},
+		{"rational", "xs = [1/3, 1/6]; sum(xs)", []Option{WithRational(Fraction, RejectInexact)}, "1/2"},
+		{"rational list", "[1/3, 2/3][-1]", []Option{WithRational(Fraction, RejectInexact)}, "2/3"},
+		{"rational range", "1/2..3", []Option{WithRational(Fraction, RejectInexact)}, "[1/2, 3/2, 5/2]"},
+		{"integer", "product(1..25)", []Option{WithInteger()}, "15511210043330985984000000"},
+		{"units", "avg([1 km, 500 m])", []Option{WithUnits()}, "0.75 km"},
+		{"units median", "median([3 m, 1 km, 20 cm])", []Option{WithUnits()}, "3 m"},
+		{"currency range", "$1..$3", []Option{WithCurrency(StaticRates{})}, "[1.00 USD, 2.00 USD, 3.00 USD]"},
+		{"range of one beyond precision", "10^20..10^20 + 3", []Option{WithPrecision(10)}, "[100000000000000000000]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_listErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+	}{
+		{"index past the end", "[1, 2][2]", nil, ErrIndex},
+		{"index before the start", "[1, 2][-3]", nil, ErrIndex},
+		{"fractional index", "[1, 2][0.5]", nil, ErrIndex},
+		{"index of number", "x = 2; x[0]", nil, ErrType},
+		{"string index", `[1]["a"]`, nil, ErrType},
+		{"list in arithmetic", "[1] + 1", nil, ErrType},
+		{"list in function", "sqrt([4])", nil, ErrType},
+		{"string element", `sum([1, "a"])`, nil, ErrType},
+		{"long range", "1..1e12", nil, ErrListLength},
+		{"infinite range", "inf..inf", nil, ErrDomain},
+		{"negative infinite range", "-inf..-inf", nil, ErrDomain},
+		{"range beyond steps of 1", "1e16..1e16 + 2", nil, ErrOverflow},
+		{"average of nothing", "avg([])", nil, ErrDomain},
+		{"maximum of nothing", "max([])", nil, ErrDomain},
+		{"deviation of one number", "stddev([1])", nil, ErrDomain},
+		{"precision index", "[1][1]", []Option{WithPrecision(34)}, ErrIndex},
+		{"rational average of nothing", "median([])", []Option{WithRational(Fraction, RejectInexact)}, ErrDomain},
+		{"rational long range", "0..2^30", []Option{WithRational(Fraction, RejectInexact)}, ErrListLength},
+		{"precision range beyond float64", "10^400..10^401", []Option{WithPrecision(34)}, ErrListLength},
+		{"rational range beyond float64", "10^400..10^401", []Option{WithRational(Fraction, RejectInexact)}, ErrListLength},
+		{"integer range beyond float64", "10^400..10^401", []Option{WithInteger()}, ErrListLength},
+		{"range beyond precision", "10^12..10^12 + 1000", []Option{WithPrecision(10)}, ErrOverflow},
+		{"range of lengths", "1 m..3 m", []Option{WithUnits()}, ErrDimension},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) || !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_rangeList(t *testing.T) {
+	tests := []struct {
+		start, end float64
+		want       List
+	}{
+		{1, 3, List{Number(1), Number(2), Number(3)}},
+		{-1, 0.5, List{Number(-1), Number(0)}},
+		{2, 2, List{Number(2)}},
+		{3, 2, List{}},
+		{1e16, 1e16, List{Number(1e16)}},
+	}
+
+	for _, tt := range tests {
+		got, err := rangeList(tt.start, tt.end)
+		if err != nil {
+			t.Fatalf("\nUnexpected error:\t%v", err)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("\nGot:\t%v\nWant:\t%v", got, tt.want)
+		}
+	}
+}
+
+func Test_elementAt(t *testing.T) {
+	list := List{Number(1), Bool(true), String("a")}
+
+	tests := []struct {
+		index   float64
+		want    Value
+		wantErr error
+	}{
+		{0, Number(1), nil},
+		{2, String("a"), nil},
+		{-1, String("a"), nil},
+		{-3, Number(1), nil},
+		{3, nil, ErrIndex},
+		{-4, nil, ErrIndex},
+		{1.5, nil, ErrIndex},
+	}
+
+	for _, tt := range tests {
+		got, err := elementAt(list, tt.index)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", got, err, tt.want, tt.wantErr)
+		}
+	}
+}
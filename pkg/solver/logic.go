@@ -0,0 +1,94 @@
+package solver
+
+// isComparison reports whether op compares two values.
+func isComparison(op string) bool {
+	switch op {
+	case less, lessOrEqual, equal, notEqual, greaterOrEqual, greater:
+		return true
+	}
+	return false
+}
+
+// compared returns the result of a comparison of two values whose order is given by sign,
+// which is negative, zero or positive as the left one is less than, equal to or greater than the right one.
+func compared(op string, sign int) bool {
+	switch op {
+	case less:
+		return sign < 0
+	case lessOrEqual:
+		return sign <= 0
+	case equal:
+		return sign == 0
+	case notEqual:
+		return sign != 0
+	case greaterOrEqual:
+		return sign >= 0
+	default:
+		return sign > 0
+	}
+}
+
+// negate applies a prefix operator to a value.
+func negate(op string, operand value) (value, error) {
+	switch {
+	case op == not && operand.typ() == BoolType:
+		return boolean(!operand.truth()), nil
+	case op == not, operand.ref != nil:
+		return value{}, &TypeError{Op: op, Left: operand.typ()}
+	case op != subtract:
+		// negation is the only prefix operator on numbers outside of integer mode
+		return value{}, unsupportedOperator(op)
+	}
+	return number(-operand.num), nil
+}
+
+// operateValues applies an infix operator other than && and || to two values.
+func operateValues(op string, left, right value) (value, error) {
+	if left.ref != nil || right.ref != nil {
+		result, err := operateOthers(floatScalars{}, op, left.public(), right.public())
+		if err != nil {
+			return value{}, err
+		}
+		return fromValue(result), nil
+	}
+
+	switch {
+	case isComparison(op):
+		return boolean(compared(op, compareFloats(left.num, right.num))), nil
+	case op == listRange:
+		list, err := rangeList(left.num, right.num)
+		if err != nil {
+			return value{}, err
+		}
+		return value{ref: list}, nil
+	}
+
+	result, err := operate(op, left.num, right.num)
+	if err != nil {
+		return value{}, err
+	}
+	return number(result), nil
+}
+
+// compareFloats returns -1, 0 or +1 as a is less than, equal to or greater than b.
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// checkBoolean returns the error of an operand of op which should be a boolean, or nil if it is one.
+// left is the type of the other operand of && and || if operand is their right one, and zero otherwise.
+func checkBoolean(op string, operand value, left Type) error {
+	switch {
+	case operand.typ() == BoolType:
+		return nil
+	case left == 0:
+		return &TypeError{Op: op, Left: operand.typ()}
+	}
+	return &TypeError{Op: op, Left: left, Right: operand.typ()}
+}
@@ -0,0 +1,290 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_logic(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"comparison", "1 < 2", "(1 < 2)"},
+		{"comparison binds looser than arithmetic", "1 + 2 >= 3 * 4", "((1 + 2) >= (3 * 4))"},
+		{"and binds tighter than or", "a || b && c", "(a || (b && c))"},
+		{"comparisons bind tighter than and", "age >= 18 && income > 30000", "((age >= 18) && (income > 30000))"},
+		{"comparisons are left-associative", "a == b != c", "((a == b) != c)"},
+		{"not binds tightest", "!a == b", "((!a) == b)"},
+		{"booleans", "true || false", "(true || false)"},
+		{"conditional", "x > 0 ? x : -x", "((x > 0) ? x : (-x))"},
+		{"conditional binds loosest", "a || b ? 1 + 2 : 3", "((a || b) ? (1 + 2) : 3)"},
+		{"conditional is right-associative", "a ? 1 : b ? 2 : 3", "(a ? 1 : (b ? 2 : 3))"},
+		{"nested conditional", "a ? b ? 1 : 2 : 3", "(a ? (b ? 1 : 2) : 3)"},
+		{"if", "if(x > 0, x, -x)", "((x > 0) ? x : (-x))"},
+		{"conditional conversion", "1 km < 1 mi ? 1 km to m : 2", "(((1 * km) < (1 * mi)) ? ((1 * km) to m) : 2)"},
+		{"comparison of percentages", "5% > 4%", "((5%) > (4%))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression, WithUnits())
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_logicErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+		wantOffset int
+		wantLength int
+	}{
+		{"conditional without colon", "a ? 1", ErrMissingColon, 2, 1},
+		{"colon without conditional", "a : 1", ErrMalformedExp, 2, 1},
+		{"if with two arguments", "if(a, 1)", ErrArgumentCount, 0, 8},
+		{"assignment to boolean", "true = 1", ErrMalformedExp, 0, 4},
+		{"definition of if", "if(a, b, c) = a", ErrMalformedExp, 12, 1},
+		{"boolean parameter", "f(false) = 1", ErrMalformedExp, 2, 5},
+		{"double not", "!!a", ErrIllegalConsecutiveOperator, 1, 1},
+		{"dangling comparison", "a <", ErrIllegalEnd, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+			if syntaxErr.Offset != tt.wantOffset || syntaxErr.Length != tt.wantLength {
+				t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", syntaxErr.Offset, syntaxErr.Length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestSolve_logic(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantResult string
+	}{
+		{"less", "1 < 2", "true"},
+		{"less or equal", "2 <= 2", "true"},
+		{"equal", "0.5 == 1/2", "true"},
+		{"not equal", "1 != 1", "false"},
+		{"greater or equal", "1 >= 2", "false"},
+		{"greater", "inf > 1e308", "true"},
+		{"and", "true && false", "false"},
+		{"or", "true || false", "true"},
+		{"not", "!false", "true"},
+		{"equal booleans", "(1 < 2) == (2 < 3)", "true"},
+		{"rule", "age = 30; income = 45000; age >= 18 && income > 30000", "true"},
+		{"conditional", "x = -4; x > 0 ? x : -x", "4"},
+		{"conditional of booleans", "1 > 2 ? false : true", "true"},
+		{"if", "if(2 > 1, 10, 20)", "10"},
+		{"nested conditional", "x = 5; x < 0 ? -1 : x == 0 ? 0 : 1", "1"},
+		{"and skips right operand", "false && 1/0 > 1", "false"},
+		{"or skips right operand", "true || 1/0 > 1", "true"},
+		{"conditional skips other branch", "x = 0; x == 0 ? 0 : 1/x", "0"},
+		{"if skips other branch", "if(true, 1, sqrt(-1))", "1"},
+		{"boolean variable", "adult = 20 >= 18; !adult", "false"},
+		{"boolean parameter", "pick(c, a, b) = c ? a : b; pick(1 > 2, 3, 4)", "4"},
+		{"recursion", "fib(n) = n < 2 ? n : fib(n - 1) + fib(n - 2); fib(10)", "55"},
+		{"percentages", "200 + 10% > 210", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestSolve_logicInModes(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantResult string
+	}{
+		{"precision", "0.1 + 0.2 == 0.3", []Option{WithPrecision(34)}, "true"},
+		{"rational", "1/3 + 1/3 < 2/3", []Option{WithRational(Fraction, RejectInexact)}, "false"},
+		{"integer", "2^100 > 2^99 && 7 // 2 == 3", []Option{WithInteger()}, "true"},
+		{"complex equality", "i^2 == -1", []Option{WithComplex()}, "true"},
+		{"complex inequality", "i != 2i", []Option{WithComplex()}, "true"},
+		{"units", "1 mi > 1 km && 100 cm == 1 m", []Option{WithUnits()}, "true"},
+		{"conditional in units", "d = 5 km; d > 3 km ? d to m : 0 m", []Option{WithUnits()}, "5000 m"},
+		{"currency", "$12.50 >= $12.5", []Option{WithCurrency(StaticRates{"USD": 1})}, "true"},
+		{"boolean variable", "big = 2^70 > 2^69; big", []Option{WithInteger()}, "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_typeErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    TypeError
+		wantOffset int
+	}{
+		{"boolean in sum", "1 + (2 > 1)", nil, TypeError{Op: add, Left: NumberType, Right: BoolType}, 2},
+		{"chained comparison", "1 < 2 < 3", nil, TypeError{Op: less, Left: BoolType, Right: NumberType}, 6},
+		{"equality of boolean and number", "true == 1", nil, TypeError{Op: equal, Left: BoolType, Right: NumberType}, 5},
+		{"boolean in chained sum", "1 + 2 + true", nil, TypeError{Op: add, Left: NumberType, Right: BoolType}, 6},
+		{"ordering of booleans", "true < false", nil, TypeError{Op: less, Left: BoolType, Right: BoolType}, 5},
+		{"number in and", "1 && true", nil, TypeError{Op: and, Left: NumberType}, 2},
+		{"number after or", "false || 1", nil, TypeError{Op: or, Left: BoolType, Right: NumberType}, 6},
+		{"not of number", "!1", nil, TypeError{Op: not, Left: NumberType}, 0},
+		{"negation of boolean", "-true", nil, TypeError{Op: subtract, Left: BoolType}, 0},
+		{"percentage of boolean", "true%", nil, TypeError{Op: percent, Left: BoolType}, 0},
+		{"number as condition", "x = 1; x ? 1 : 2", nil, TypeError{Op: question, Left: NumberType}, 7},
+		{"function of boolean", "sqrt(true)", nil, TypeError{Op: "sqrt", Left: BoolType}, 0},
+		{"boolean in function body", "f(x) = x + 1; f(true)", nil, TypeError{Op: add, Left: BoolType, Right: NumberType}, 14},
+		{"precision", "1 + true", []Option{WithPrecision(34)}, TypeError{Op: add, Left: NumberType, Right: BoolType}, 2},
+		{"integer", "~true", []Option{WithInteger()}, TypeError{Op: bitNot, Left: BoolType}, 0},
+		{"units", "if(1 m, 1, 2)", []Option{WithUnits()}, TypeError{Op: question, Left: NumberType}, 3},
+		{"currency", "$1 && true", []Option{WithCurrency(StaticRates{"USD": 1})}, TypeError{Op: and, Left: NumberType}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			var typeErr *TypeError
+			if !errors.As(err, &typeErr) || !errors.Is(err, ErrType) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, ErrType)
+			}
+			if *typeErr != tt.wantErr {
+				t.Errorf("\nGot:\t%+v\nWant:\t%+v", *typeErr, tt.wantErr)
+			}
+
+			var evalErr *EvalError
+			if errors.As(err, &evalErr) && evalErr.Offset != tt.wantOffset {
+				t.Errorf("\nGot:\t%d\nWant:\t%d", evalErr.Offset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestEval_comparisonErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+	}{
+		{"ordering of complex numbers", "i < 2", []Option{WithComplex()}, ErrNotReal},
+		{"different dimensions", "1 m < 1 s", []Option{WithUnits()}, ErrDimension},
+		{"different currencies", "$1 == 1 EUR", []Option{WithCurrency(StaticRates{"USD": 1, "EUR": 1})}, ErrCurrency},
+		{"error in chosen branch", "true ? 1/0 : 1", nil, ErrDivisionByZero},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResult_Bool(t *testing.T) {
+	tests := []struct {
+		expression string
+		opts       []Option
+		wantBool   bool
+		wantOk     bool
+		wantFloat  float64
+	}{
+		{"2 > 1", nil, true, true, 1},
+		{"2 < 1", nil, false, true, 0},
+		{"2 > 1", []Option{WithRational(Fraction, RejectInexact)}, true, true, 1},
+		{"2 + 1", nil, false, false, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Eval(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			b, ok := result.Bool()
+			if b != tt.wantBool || ok != tt.wantOk || result.Float() != tt.wantFloat {
+				t.Errorf("\nGot:\t%v, %v, %v\nWant:\t%v, %v, %v", b, ok, result.Float(), tt.wantBool, tt.wantOk, tt.wantFloat)
+			}
+		})
+	}
+}
+
+func TestEvalWithEnv_logic(t *testing.T) {
+	env := NewEnv(map[string]float64{"age": 17, "income": 45000})
+	env.SetBool("student", true)
+
+	if err := env.Define("eligible", []string{"age", "income"}, "age >= 18 && income > 30000 || student"); err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	result, err := EvalWithEnv("ok = eligible(age, income)", env)
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "true" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "true")
+	}
+
+	// booleans keep their type for later evaluations, in any mode
+	result, err = EvalWithEnv("!ok", env, WithPrecision(34))
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "false" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "false")
+	}
+
+	// Get returns 1 for true
+	if value, ok := env.Get("ok"); value != 1 || !ok {
+		t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", value, ok, 1, true)
+	}
+
+	// a number assigned over a boolean is a number again
+	env.Set("student", 1)
+	_, err = EvalWithEnv("eligible(age, income)", env)
+	if !errors.Is(err, ErrType) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrType)
+	}
+}
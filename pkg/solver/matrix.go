@@ -0,0 +1,501 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ShapeError describes vectors or matrices whose sizes don't suit an operation,
+// such as the product of two 2x3 matrices. It wraps ErrShape so that it can be matched with errors.Is.
+type ShapeError struct {
+	// Op is the operator or function applied.
+	Op string
+	// Left and Right are the sizes of the operands, written as rows x columns for matrices, as in 2x3,
+	// and as the length of other lists. Right is empty for functions of a single matrix.
+	Left, Right string
+}
+
+func (e *ShapeError) Error() string {
+	if e.Right == "" {
+		return fmt.Sprintf("%s for %s: %s", ErrShape, e.Op, e.Left)
+	}
+	return fmt.Sprintf("%s for %s: %s and %s", ErrShape, e.Op, e.Left, e.Right)
+}
+
+func (e *ShapeError) Unwrap() error {
+	return ErrShape
+}
+
+// scalars is the arithmetic of the numbers in vectors and matrices, which is that of float64
+// in the virtual machine, and that of the number system in other modes.
+type scalars interface {
+	// operate applies an arithmetic operator to two values which aren't lists.
+	operate(op string, left, right Value) (Value, error)
+	// number returns a small integer, such as 0 or 1, as a number.
+	number(n int) (Value, error)
+	// magnitude approximates the absolute value of a number, by which pivots are chosen.
+	magnitude(x Value) float64
+}
+
+// floatScalars is the arithmetic of float64 numbers.
+type floatScalars struct{}
+
+func (floatScalars) operate(op string, left, right Value) (Value, error) {
+	result, err := operateValues(op, fromValue(left), fromValue(right))
+	if err != nil {
+		return nil, err
+	}
+	return result.public(), nil
+}
+
+func (floatScalars) number(n int) (Value, error) {
+	return Number(n), nil
+}
+
+func (floatScalars) magnitude(x Value) float64 {
+	return math.Abs(approximate(x))
+}
+
+// systemScalars is the arithmetic of the numbers of a number system.
+type systemScalars struct {
+	system numberSystem
+}
+
+func (s systemScalars) operate(op string, left, right Value) (Value, error) {
+	if left.Type() != NumberType || right.Type() != NumberType {
+		return operateOthers(s, op, left, right)
+	}
+
+	l, err := numberIn(s.system, left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := numberIn(s.system, right)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.system.operate(op, l, r)
+	if err != nil {
+		return nil, err
+	}
+	return valueIn(s.system, result), nil
+}
+
+func (s systemScalars) number(n int) (Value, error) {
+	x, err := s.system.fromFloat(float64(n))
+	if err != nil {
+		return nil, err
+	}
+	return systemNumber{system: s.system, x: x}, nil
+}
+
+func (s systemScalars) magnitude(x Value) float64 {
+	n, err := numberIn(s.system, x)
+	if err == nil {
+		// the absolute value of complex numbers is real
+		n, err = s.system.call("abs", functions["abs"], []interface{}{n})
+	}
+	if err != nil {
+		return 0
+	}
+	return math.Abs(s.system.float(n))
+}
+
+// matrixFunction wraps a function of a single matrix called name.
+func matrixFunction(name string, f func(s scalars, rows []List) (Value, error)) function {
+	return function{minArgs: 1, maxArgs: 1, matrix: func(s scalars, args []Value) (Value, error) {
+		rows, err := matrixArgument(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(s, rows)
+	}}
+}
+
+// matrixOf returns the rows of a matrix, which is a non-empty list of non-empty lists of numbers of the same length.
+func matrixOf(v Value) ([]List, bool) {
+	list, ok := v.(List)
+	if !ok || len(list) == 0 {
+		return nil, false
+	}
+
+	rows := make([]List, len(list))
+	for i, element := range list {
+		row, ok := element.(List)
+		if !ok || len(row) == 0 || !isVector(row) || i > 0 && len(row) != len(rows[0]) {
+			return nil, false
+		}
+		rows[i] = row
+	}
+	return rows, true
+}
+
+// isVector reports whether a list only holds numbers.
+func isVector(list List) bool {
+	for _, element := range list {
+		if element.Type() != NumberType {
+			return false
+		}
+	}
+	return true
+}
+
+// otherType returns the type of the first value in a list, or in the lists it holds, which isn't a number,
+// and zero if they only hold numbers.
+func otherType(list List) Type {
+	for _, element := range list {
+		switch element := element.(type) {
+		case List:
+			if t := otherType(element); t != 0 {
+				return t
+			}
+		default:
+			if element.Type() != NumberType {
+				return element.Type()
+			}
+		}
+	}
+	return 0
+}
+
+// sizeOf returns the size of a list as written in shape errors.
+func sizeOf(list List) string {
+	if rows, ok := matrixOf(list); ok {
+		return fmt.Sprintf("%dx%d", len(rows), len(rows[0]))
+	}
+	return strconv.Itoa(len(list))
+}
+
+// matrixArgument returns the rows of an argument of a function which takes matrices, or the error it is reported with:
+// a TypeError if it isn't a list or holds values other than numbers, and a ShapeError otherwise.
+// Vectors are taken as matrices of a single row.
+func matrixArgument(name string, v Value) ([]List, error) {
+	if rows, ok := matrixOf(v); ok {
+		return rows, nil
+	}
+
+	list, ok := v.(List)
+	switch {
+	case !ok:
+		return nil, &TypeError{Op: name, Left: v.Type()}
+	case otherType(list) != 0:
+		return nil, &TypeError{Op: name, Left: otherType(list)}
+	case len(list) > 0 && isVector(list):
+		return []List{list}, nil
+	}
+	return nil, &ShapeError{Op: name, Left: sizeOf(list)}
+}
+
+// operateLists applies an arithmetic operator to two values of which at least one is a list.
+// Lists of the same shape are added and subtracted element by element, lists are multiplied and divided
+// by numbers element by element, .* multiplies lists element by element, and * multiplies vectors and matrices
+// as matrices. ok is false if lists can't be combined by op.
+func operateLists(s scalars, op string, left, right Value) (result Value, ok bool, err error) {
+	l, leftList := left.(List)
+	r, rightList := right.(List)
+
+	switch {
+	case !leftList && !rightList:
+		return nil, false, nil
+	case op == multiply && leftList && rightList:
+		result, err = multiplyMatrices(s, l, r)
+	case (op == add || op == subtract) && leftList && rightList,
+		op == multiplyElements, op == multiply, op == divide && !rightList:
+		if leftList && rightList && !sameShape(l, r) {
+			return nil, true, &ShapeError{Op: op, Left: sizeOf(l), Right: sizeOf(r)}
+		}
+		result, err = elementwise(s, op, left, right)
+	default:
+		return nil, false, nil
+	}
+	return result, true, err
+}
+
+// sameShape reports whether two lists have the same length, and whether the lists at the same positions in them do.
+func sameShape(a, b List) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		l, leftList := a[i].(List)
+		r, rightList := b[i].(List)
+		if leftList != rightList || leftList && !sameShape(l, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// elementwise applies an operator to the values at the same positions in two lists of the same shape,
+// or to the values in a list and another value.
+func elementwise(s scalars, op string, left, right Value) (Value, error) {
+	l, leftList := left.(List)
+	r, rightList := right.(List)
+	if !leftList && !rightList {
+		if op == multiplyElements {
+			op = multiply
+		}
+		return s.operate(op, left, right)
+	}
+
+	length := len(l)
+	if !leftList {
+		length = len(r)
+	}
+
+	result := make(List, length)
+	for i := range result {
+		a, b := left, right
+		if leftList {
+			a = l[i]
+		}
+		if rightList {
+			b = r[i]
+		}
+
+		var err error
+		if result[i], err = elementwise(s, op, a, b); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// multiplyMatrices returns the matrix product of two vectors or matrices. A vector is a row on the left of the product
+// and a column on its right, so that the product of a matrix and a vector is a vector,
+// and the product of two vectors is their dot product.
+func multiplyMatrices(s scalars, left, right List) (Value, error) {
+	if otherType(left) != 0 || otherType(right) != 0 {
+		return nil, &TypeError{Op: multiply, Left: ListType, Right: ListType}
+	}
+
+	a, leftOk := matrixOf(left)
+	b, rightOk := matrixOf(right)
+	leftVector, rightVector := !leftOk && len(left) > 0 && isVector(left), !rightOk && len(right) > 0 && isVector(right)
+	if leftVector {
+		a = []List{left}
+	}
+	if rightVector {
+		b = transpose([]List{right})
+	}
+	if !leftOk && !leftVector || !rightOk && !rightVector || len(a[0]) != len(b) {
+		return nil, &ShapeError{Op: multiply, Left: sizeOf(left), Right: sizeOf(right)}
+	}
+
+	product := make([]List, len(a))
+	for i, row := range a {
+		product[i] = make(List, len(b[0]))
+		for j := range product[i] {
+			var sum Value
+			for k := range row {
+				term, err := s.operate(multiply, row[k], b[k][j])
+				if err == nil && k > 0 {
+					term, err = s.operate(add, sum, term)
+				}
+				if err != nil {
+					return nil, err
+				}
+				sum = term
+			}
+			product[i][j] = sum
+		}
+	}
+
+	switch {
+	case leftVector && rightVector:
+		return product[0][0], nil
+	case leftVector:
+		return product[0], nil
+	case rightVector:
+		return transpose(product)[0], nil
+	}
+	return matrix(product), nil
+}
+
+// matrix returns the list of the rows of a matrix.
+func matrix(rows []List) List {
+	list := make(List, len(rows))
+	for i, row := range rows {
+		list[i] = row
+	}
+	return list
+}
+
+// transpose returns the columns of a matrix.
+func transpose(rows []List) []List {
+	columns := make([]List, len(rows[0]))
+	for j := range columns {
+		columns[j] = make(List, len(rows))
+		for i, row := range rows {
+			columns[j][i] = row[j]
+		}
+	}
+	return columns
+}
+
+// transposed returns the transpose of a matrix, which turns a vector into a matrix of a single column.
+func transposed(s scalars, rows []List) (Value, error) {
+	return matrix(transpose(rows)), nil
+}
+
+// determinant returns the determinant of a square matrix, which is 0 for singular ones.
+func determinant(s scalars, rows []List) (Value, error) {
+	if len(rows) != len(rows[0]) {
+		return nil, &ShapeError{Op: "det", Left: sizeOf(matrix(rows))}
+	}
+
+	_, det, err := reduce(s, rows, nil)
+	if errors.Is(err, ErrSingular) {
+		return s.number(0)
+	}
+	return det, err
+}
+
+// inverse returns the inverse of a square matrix.
+func inverse(s scalars, rows []List) (Value, error) {
+	if len(rows) != len(rows[0]) {
+		return nil, &ShapeError{Op: "inv", Left: sizeOf(matrix(rows))}
+	}
+
+	identity := make([]List, len(rows))
+	for i := range identity {
+		identity[i] = make(List, len(rows))
+		for j := range identity[i] {
+			n := 0
+			if i == j {
+				n = 1
+			}
+
+			var err error
+			if identity[i][j], err = s.number(n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	inverted, _, err := reduce(s, rows, identity)
+	if err != nil {
+		return nil, err
+	}
+	return matrix(inverted), nil
+}
+
+// solveLinear returns the solution x of the linear system A x = b, where A is a square matrix
+// and b is a vector, or a matrix whose columns are solved for separately.
+func solveLinear(s scalars, args []Value) (Value, error) {
+	a, err := matrixArgument("solve", args[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := matrixArgument("solve", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	// a vector is a column of constants rather than a matrix of a single row
+	_, isMatrix := matrixOf(args[1])
+	if !isMatrix {
+		b = transpose(b)
+	}
+	if len(a) != len(a[0]) || len(b) != len(a) {
+		return nil, &ShapeError{Op: "solve", Left: sizeOf(matrix(a)), Right: sizeOf(args[1].(List))}
+	}
+
+	x, _, err := reduce(s, a, b)
+	switch {
+	case err != nil:
+		return nil, err
+	case !isMatrix:
+		return transpose(x)[0], nil
+	}
+	return matrix(x), nil
+}
+
+// reduce solves the linear systems of a square matrix and each column of rhs, which may be nil,
+// by fraction-free Gauss-Jordan elimination, and also returns the determinant of the matrix.
+// All of its divisions but the last ones are exact for matrices of integers, which keeps their determinants exact
+// in integer mode, and its products keep the units of quantities consistent. Singular matrices fail with ErrSingular.
+func reduce(s scalars, a, rhs []List) ([]List, Value, error) {
+	n := len(a)
+	m := make([]List, n)
+	for i := range a {
+		m[i] = append(List{}, a[i]...)
+		if rhs != nil {
+			m[i] = append(m[i], rhs[i]...)
+		}
+	}
+
+	previous, err := s.number(1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	negative := false
+	for k := 0; k < n; k++ {
+		// the largest pivot is chosen, which keeps errors of float64 arithmetic small
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if s.magnitude(m[i][k]) > s.magnitude(m[pivot][k]) {
+				pivot = i
+			}
+		}
+		if s.magnitude(m[pivot][k]) == 0 {
+			return nil, nil, ErrSingular
+		}
+		if pivot != k {
+			m[k], m[pivot] = m[pivot], m[k]
+			negative = !negative
+		}
+
+		// the columns left of k are eliminated already, and aren't needed for the solutions
+		for i := range m {
+			if i == k {
+				continue
+			}
+			for j := k + 1; j < len(m[i]); j++ {
+				x, err := s.operate(multiply, m[k][k], m[i][j])
+				if err != nil {
+					return nil, nil, err
+				}
+				y, err := s.operate(multiply, m[i][k], m[k][j])
+				if err == nil {
+					x, err = s.operate(subtract, x, y)
+				}
+				if err == nil {
+					m[i][j], err = s.operate(divide, x, previous)
+				}
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		previous = m[k][k]
+	}
+
+	// the last pivot is the determinant up to its sign, and every row of the solutions was multiplied by it
+	det := previous
+	if negative {
+		minusOne, err := s.number(-1)
+		if err == nil {
+			det, err = s.operate(multiply, det, minusOne)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	solutions := make([]List, n)
+	for i := range solutions {
+		solutions[i] = make(List, len(m[i])-n)
+		for j := range solutions[i] {
+			if solutions[i][j], err = s.operate(divide, m[i][n+j], previous); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return solutions, det, nil
+}
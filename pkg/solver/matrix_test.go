@@ -0,0 +1,191 @@
+package solver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse_matrices(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"matrix", "[[1, 2], [3, 4]]", "[[1, 2], [3, 4]]"},
+		{"element-wise product", "a .* b + c", "((a .* b) + c)"},
+		{"without spaces", "a.*b", "(a .* b)"},
+		{"product of function", "inv(a) * b", "(inv(a) * b)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestSolve_matrices(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantResult string
+	}{
+		{"product", "[[1, 2], [3, 4]] * [[5, 6], [7, 8]]", nil, "[[19, 22], [43, 50]]"},
+		{"element-wise product", "[[1, 2], [3, 4]] .* [[5, 6], [7, 8]]", nil, "[[5, 12], [21, 32]]"},
+		{"element-wise product of numbers", "2 .* 3", nil, "6"},
+		{"product of matrix and vector", "[[1, 2], [3, 4]] * [1, 1]", nil, "[3, 7]"},
+		{"product of vector and matrix", "[1, 1] * [[1, 2], [3, 4]]", nil, "[4, 6]"},
+		{"dot product", "[1, 2, 3] * [4, 5, 6]", nil, "32"},
+		{"sum", "[[1, 2], [3, 4]] + [[1, 1], [1, 1]]", nil, "[[2, 3], [4, 5]]"},
+		{"difference of vectors", "[3, 4] - [1, 2]", nil, "[2, 2]"},
+		{"scaling", "2 * [[1, 2], [3, 4]] / 4", nil, "[[0.5, 1], [1.5, 2]]"},
+		{"transpose", "transpose([[1, 2, 3], [4, 5, 6]])", nil, "[[1, 4], [2, 5], [3, 6]]"},
+		{"transpose of vector", "transpose([1, 2])", nil, "[[1], [2]]"},
+		{"determinant", "det([[6, 1, 1], [4, -2, 5], [2, 8, 7]])", nil, "-306"},
+		{"determinant of singular matrix", "det([[1, 2], [2, 4]])", nil, "0"},
+		{"inverse", "inv([[1, 2], [3, 4]])", nil, "[[-2, 1], [1.5, -0.5]]"},
+		{"inverse times matrix", "a = [[4, 3], [6, 3]]; inv(a) * a == [[1, 0], [0, 1]]", nil, "true"},
+		{"linear system", "solve([[2, 1], [1, 3]], [3, 5])", nil, "[0.8, 1.4]"},
+		{"linear systems", "solve([[2, 0], [0, 4]], [[2, 4], [4, 8]])", nil, "[[1, 2], [1, 2]]"},
+		{"user-defined function", "sq(m) = m * m; sq([[1, 1], [0, 1]])", nil, "[[1, 2], [0, 1]]"},
+		{"precision", "inv([[1, 2], [3, 4]])", []Option{WithPrecision(34)}, "[[-2, 1], [1.5, -0.5]]"},
+		{"rational inverse", "inv([[1, 2], [3, 4]])", []Option{WithRational(Fraction, RejectInexact)}, "[[-2, 1], [3/2, -1/2]]"},
+		{"rational system", "solve([[2, 1], [1, 3]], [3, 5])", []Option{WithRational(Fraction, RejectInexact)}, "[4/5, 7/5]"},
+		{"rational determinant", "det([[1/2, 1/3], [1/4, 1/5]])", []Option{WithRational(Fraction, RejectInexact)}, "1/60"},
+		{"integer determinant", "det([[6, 1, 1], [4, -2, 5], [2, 8, 7]])", []Option{WithInteger()}, "-306"},
+		{"integer inverse", "inv([[2, 1], [1, 1]])", []Option{WithInteger()}, "[[1, -1], [-1, 2]]"},
+		{"complex inverse", "inv([[i, 1], [0, 2]])", []Option{WithComplex()}, "[[-i, 0.5i], [0, 0.5]]"},
+		{"units determinant", "det([[1 m, 2 m], [3 m, 4 m]])", []Option{WithUnits()}, "-2 m^2"},
+		{"units system", "solve([[2 m, 1 m], [1 m, 3 m]], [3 N, 5 N])", []Option{WithUnits()}, "[0.8 N/m, 1.4 N/m]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_matrixErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+		wantMsg    string
+	}{
+		{"product", "[[1, 2], [3, 4]] * [[1, 2, 3]]", nil, ErrShape,
+			`incompatible matrix sizes for *: 2x2 and 1x3: "[[1, 2], [3, 4]] * [[1, 2, 3]]" at column 1`},
+		{"sum", "[1, 2] + [1, 2, 3]", nil, ErrShape,
+			`incompatible matrix sizes for +: 2 and 3: "[1, 2] + [1, 2, 3]" at column 1`},
+		{"element-wise product", "[[1, 2]] .* [[1], [2]]", nil, ErrShape,
+			`incompatible matrix sizes for .*: 1x2 and 2x1: "[[1, 2]] .* [[1], [2]]" at column 1`},
+		{"determinant", "det([[1, 2, 3], [4, 5, 6]])", nil, ErrShape,
+			`incompatible matrix sizes for det: 2x3: "det([[1, 2, 3], [4, 5, 6]])" at column 1`},
+		{"linear system", "solve([[1, 0], [0, 1]], [1, 2, 3])", nil, ErrShape,
+			`incompatible matrix sizes for solve: 2x2 and 3: "solve([[1, 0], [0, 1]], [1, 2, 3])" at column 1`},
+		{"ragged matrix", "inv([[1], [1, 2]])", nil, ErrShape,
+			`incompatible matrix sizes for inv: 2: "inv([[1], [1, 2]])" at column 1`},
+		{"singular inverse", "inv([[1, 2], [2, 4]])", nil, ErrSingular,
+			`matrix is singular: "inv([[1, 2], [2, 4]])" at column 1`},
+		{"singular system", "solve([[0, 0], [0, 0]], [1, 1])", []Option{WithRational(Fraction, RejectInexact)}, ErrSingular,
+			`matrix is singular: "solve([[0, 0], [0, 0]], [1, 1])" at column 1`},
+		{"determinant of number", "det(5)", nil, ErrType,
+			`cannot call det with number: "det(5)" at column 1`},
+		{"inverse of strings", `inv([["a"]])`, nil, ErrType,
+			`cannot call inv with string: "inv([[\"a\"]])" at column 1`},
+		{"product of strings", `["a"] * [1]`, nil, ErrType,
+			`cannot multiply list and list: "*" at column 7`},
+		{"element-wise product of string", `[1, "a"] .* 2`, nil, ErrType,
+			`cannot multiply string and number: ".*" at column 10`},
+		{"sum of list and number", "[1] + 1", nil, ErrType,
+			`cannot add list and number: "+" at column 5`},
+		{"number divided by list", "1 / [1]", nil, ErrType,
+			`cannot divide number and list: "/" at column 3`},
+		{"integer inverse", "inv([[1, 2], [3, 4]])", []Option{WithInteger()}, ErrNotInteger,
+			`result is not an integer: "inv([[1, 2], [3, 4]])" at column 1`},
+		{"units sum", "[1 m] + [1 s]", []Option{WithUnits()}, ErrDimension,
+			`incompatible dimensions for +: m and s: "[1 m] + [1 s]" at column 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+			if err.Error() != tt.wantMsg {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestResult_Rows(t *testing.T) {
+	tests := []struct {
+		expression string
+		opts       []Option
+		wantRows   [][]string
+		wantOk     bool
+	}{
+		{"[[1, 2.5], [3, 4]]", nil, [][]string{{"1", "2.5"}, {"3", "4"}}, true},
+		{"[[1, 2]]", nil, [][]string{{"1", "2"}}, true},
+		{"inv([[1, 2], [3, 4]])", []Option{WithRational(Fraction, RejectInexact)}, [][]string{{"-2", "1"}, {"3/2", "-1/2"}}, true},
+		{"[1, 2]", nil, nil, false},
+		{"[[1], [2, 3]]", nil, nil, false},
+		{`[["a"]]`, nil, nil, false},
+		{"[[]]", nil, nil, false},
+		{"2", nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Eval(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			rows, ok := result.Rows()
+			if ok != tt.wantOk || !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", rows, ok, tt.wantRows, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_reduce(t *testing.T) {
+	a := []List{
+		{Number(0), Number(2), Number(1)},
+		{Number(1), Number(1), Number(0)},
+		{Number(2), Number(0), Number(3)},
+	}
+	rhs := []List{{Number(7)}, {Number(3)}, {Number(11)}}
+
+	solutions, det, err := reduce(floatScalars{}, a, rhs)
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	want := []List{{Number(1)}, {Number(2)}, {Number(3)}}
+	if !reflect.DeepEqual(solutions, want) || det != Number(-8) {
+		t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", solutions, det, want, Number(-8))
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !race
+// +build !race
+
+package solver
+
+// raceEnabled reports whether the race detector is on, which makes sync.Pool drop machines at random.
+const raceEnabled = false
@@ -0,0 +1,145 @@
+package solver
+
+// DefaultMaxDepth is the deepest level of nested parentheses allowed
+// when no limit is set with WithMaxDepth.
+const DefaultMaxDepth = 100
+
+// DefaultMaxCallDepth is the deepest level of nested user-defined function calls allowed
+// when no limit is set with WithMaxCallDepth.
+const DefaultMaxCallDepth = 256
+
+// Option configures how expressions are validated and solved.
+type Option func(*config)
+
+// config holds the settings applied by a list of options.
+type config struct {
+	maxDepth     int
+	maxCallDepth int
+	// system is the number system expressions are evaluated in, or nil for float64.
+	system numberSystem
+}
+
+// WithMaxDepth limits how deeply parentheses may be nested.
+// It guards against expressions large enough to exhaust the stack.
+// A negative depth is treated as 0, which disallows parentheses entirely.
+func WithMaxDepth(depth int) Option {
+	return func(c *config) {
+		if depth < 0 {
+			depth = 0
+		}
+		c.maxDepth = depth
+	}
+}
+
+// WithMaxCallDepth limits how deeply calls of user-defined functions may be nested,
+// which stops runaway recursion. A negative depth is treated as 0, which disallows
+// calling user-defined functions entirely.
+func WithMaxCallDepth(depth int) Option {
+	return func(c *config) {
+		if depth < 0 {
+			depth = 0
+		}
+		c.maxCallDepth = depth
+	}
+}
+
+// WithPrecision evaluates expressions with arbitrary-precision decimal numbers rounded to the given
+// number of significant digits instead of float64, so that 0.1 + 0.2 is exactly 0.3.
+// Powers with fractional exponents and functions other than sqrt, abs, floor, ceil, round, trunc,
+// min and max are still computed to the precision of float64.
+// A precision of 0 or less restores float64 arithmetic after an earlier WithPrecision,
+// and leaves the numbers chosen by other options, such as WithRational, alone.
+func WithPrecision(digits int) Option {
+	return func(c *config) {
+		switch _, isDecimal := c.system.(decimalSystem); {
+		case digits > 0:
+			c.system = decimalSystem{digits: digits}
+		case isDecimal:
+			c.system = nil
+		}
+	}
+}
+
+// RationalFormat selects how results of evaluations with WithRational are written.
+type RationalFormat int
+
+const (
+	// Fraction writes results as fractions in lowest terms, such as 7/2.
+	Fraction RationalFormat = iota
+	// MixedNumber writes results as a whole number and a proper fraction, such as 3 1/2.
+	MixedNumber
+	// DecimalExpansion writes results as decimals with their repeating digits in parentheses, such as 0.1(6).
+	DecimalExpansion
+)
+
+// InexactPolicy selects what happens in evaluations with WithRational
+// when a result can't be rational, such as sqrt(2) or sin(1).
+type InexactPolicy int
+
+const (
+	// RejectInexact fails the evaluation with ErrNotRational.
+	RejectInexact InexactPolicy = iota
+	// ApproximateInexact approximates the result with a float64 and reports it in the warnings of the result.
+	// Everything computed from an approximation is approximate too.
+	ApproximateInexact
+)
+
+// WithRational evaluates expressions with exact rational numbers instead of float64,
+// so that 1/3 + 1/6 is exactly 1/2, and writes results in the given format.
+// Functions, powers and constants whose results aren't rational are handled according to inexact.
+func WithRational(format RationalFormat, inexact InexactPolicy) Option {
+	return func(c *config) {
+		c.system = rationalSystem{style: format, inexact: inexact}
+	}
+}
+
+// WithInteger evaluates expressions with integers of any size instead of float64, so that 2^200 is exact,
+// and allows the bitwise operators &, |, xor, ~, << and >>, which are rejected with ErrIntegerOperator otherwise.
+// Division with / must be exact, while // and % are the floor and remainder of integer division.
+// Non-integer literals, constants and function results fail with ErrNotInteger.
+func WithInteger() Option {
+	return func(c *config) {
+		c.system = integerSystem{}
+	}
+}
+
+// WithComplex evaluates expressions with complex128 numbers instead of float64, and defines i as the imaginary unit,
+// so that 3+4i is a complex number and sqrt(-1) is i rather than a domain error.
+// Functions such as floor, min and factorial, and the operators // and %, only accept real numbers,
+// and fail with ErrNotReal otherwise.
+func WithComplex() Option {
+	return func(c *config) {
+		c.system = complexSystem{}
+	}
+}
+
+// WithUnits evaluates expressions with physical quantities, so that 3 km + 200 m is 3.2 km,
+// and allows converting them between units with the operator to, or its alias in, as in 60 mph to km/h.
+// Units such as m, kg, s, N and their SI-prefixed forms are defined as names, which variables take precedence over.
+// Operations on quantities whose dimensions don't allow them, such as 3 m + 2 s,
+// fail with a *DimensionError, while to and in are rejected with ErrUnitOperator in other modes.
+func WithUnits() Option {
+	return func(c *config) {
+		c.system = unitSystem{}
+	}
+}
+
+// WithCurrency evaluates expressions with amounts of money, written with a symbol such as $12.50 or €3
+// or followed by a currency code such as 15 EUR, and computed with decimal numbers of 34 significant digits.
+// Amounts are converted between currencies with the operator to, or its alias in, as in 100 USD to EUR,
+// using the exchange rates of the given provider. Combining different currencies without converting them,
+// as in $1 + 1 EUR, fails with a *CurrencyError. Every name of three capital letters is a currency code.
+func WithCurrency(rates RateProvider) Option {
+	return func(c *config) {
+		c.system = currencySystem{decimal: decimalSystem{digits: currencyPrecision}, rates: rates}
+	}
+}
+
+// newConfig returns the default settings with the given options applied.
+func newConfig(opts []Option) *config {
+	c := &config{maxDepth: DefaultMaxDepth, maxCallDepth: DefaultMaxCallDepth}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
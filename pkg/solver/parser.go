@@ -0,0 +1,730 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// binding powers of the supported operators, from loosest to tightest
+const (
+	precedenceLowest = iota
+	precedenceConditional
+	precedenceOr
+	precedenceAnd
+	precedenceComparison
+	precedenceRange
+	precedenceConversion
+	precedenceBitOr
+	precedenceBitXor
+	precedenceBitAnd
+	precedenceShift
+	precedenceAdditive
+	precedenceMultiplicative
+	precedenceQuantity
+	precedenceUnary
+	precedencePower
+)
+
+// Parse converts an expression into its abstract syntax tree.
+// The expression may be a program of several statements separated by semicolons,
+// in which case a *ProgramNode is returned.
+func Parse(expr string, opts ...Option) (Node, error) {
+	return parse(expr, newConfig(opts))
+}
+
+// parse converts an expression into its abstract syntax tree using the given settings.
+func parse(expr string, cfg *config) (Node, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, locateStatement(err.(*SyntaxError), expr, tokens)
+	}
+
+	_, integer := cfg.system.(integerSystem)
+	_, units := cfg.system.(unitSystem)
+	_, currency := cfg.system.(currencySystem)
+	p := &parser{
+		expr: expr, tokens: tokens, maxDepth: cfg.maxDepth,
+		integer: integer, units: units, currency: currency, exact: cfg.system != nil,
+	}
+	node, err := p.parseProgram()
+	if err != nil {
+		return nil, locateStatement(err.(*SyntaxError), expr, tokens)
+	}
+
+	return node, nil
+}
+
+// locateStatement records which statement of a program a syntax error occurred in.
+// tokens holds the tokens of the program up to at least the error.
+func locateStatement(err *SyntaxError, expr string, tokens []token) *SyntaxError {
+	statement := 1
+	multiple := strings.Contains(expr[err.Offset:], semicolon)
+
+	for _, tok := range tokens {
+		if tok.kind == tokenSemicolon {
+			multiple = true
+			if tok.pos < err.Offset {
+				statement++
+			}
+		}
+	}
+
+	if multiple {
+		err.Statement = statement
+	}
+	return err
+}
+
+// parser is a Pratt parser over a list of tokens.
+type parser struct {
+	expr     string
+	tokens   []token
+	pos      int
+	depth    int
+	maxDepth int
+	// integer, units and currency report whether the operators of those modes are allowed,
+	// and exact whether numbers are evaluated in a system with a greater range than float64.
+	integer  bool
+	units    bool
+	currency bool
+	exact    bool
+}
+
+// peek returns the current token without consuming it.
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token.
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseProgram parses statements separated by semicolons, with an optional trailing semicolon.
+// A single statement is returned as is, while several are wrapped in a ProgramNode.
+func (p *parser) parseProgram() (Node, error) {
+	var statements []Node
+
+	for {
+		statement, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+
+		if p.peek().kind != tokenSemicolon {
+			break
+		}
+
+		p.next()
+		if p.peek().kind == tokenEOF {
+			break
+		}
+	}
+
+	// every token must have been consumed by well-formed statements
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected(p.peek())
+	}
+
+	if len(statements) == 1 {
+		return statements[0], nil
+	}
+
+	first, last := statements[0], statements[len(statements)-1]
+	return &ProgramNode{span: span{first.Pos(), last.End()}, Statements: statements}, nil
+}
+
+// parseStatement parses an assignment to a name, a function definition or an expression.
+func (p *parser) parseStatement() (Node, error) {
+	if p.isDefinition() {
+		return p.parseDefinition()
+	}
+
+	if p.peek().kind != tokenIdentifier || p.tokens[p.pos+1].kind != tokenAssign {
+		return p.parseExpression(precedenceLowest)
+	}
+	if keywords[p.peek().value] {
+		return nil, p.errorAt(p.peek(), ErrMalformedExp)
+	}
+
+	name := p.next()
+	p.next()
+
+	value, err := p.parseExpression(precedenceLowest)
+	if err != nil {
+		return nil, err
+	}
+	return &AssignNode{span: span{name.pos, value.End()}, Name: name.value, Value: value}, nil
+}
+
+// isDefinition reports whether the current statement is a function definition,
+// meaning a name is followed by a parenthesized list and then an assignment.
+func (p *parser) isDefinition() bool {
+	if p.peek().kind != tokenIdentifier || p.tokens[p.pos+1].kind != tokenOpenParen || keywords[p.peek().value] {
+		return false
+	}
+
+	depth := 0
+	for i := p.pos + 1; i < len(p.tokens); i++ {
+		switch p.tokens[i].kind {
+		case tokenOpenParen:
+			depth++
+		case tokenCloseParen:
+			depth--
+			if depth == 0 {
+				// the closing parenthesis is followed by at least the end of the tokens
+				return p.tokens[i+1].kind == tokenAssign
+			}
+		case tokenEOF, tokenSemicolon:
+			return false
+		}
+	}
+
+	return false
+}
+
+// parseDefinition parses a function definition such as "f(x, y) = x^2 + y".
+func (p *parser) parseDefinition() (Node, error) {
+	name := p.next()
+	p.next()
+
+	var params []string
+	for p.peek().kind != tokenCloseParen || len(params) > 0 {
+		param := p.next()
+		if param.kind != tokenIdentifier || keywords[param.value] {
+			// parameters can only be names
+			return nil, p.errorAt(param, ErrMalformedExp)
+		}
+
+		for _, existing := range params {
+			if existing == param.value {
+				// each parameter must have a distinct name
+				return nil, p.errorAt(param, ErrMalformedExp)
+			}
+		}
+		params = append(params, param.value)
+
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+
+	if closing := p.next(); closing.kind != tokenCloseParen {
+		// parameters are only separated by commas
+		return nil, p.expected(closing, ErrMalformedExp, ")")
+	}
+	p.next()
+
+	body, err := p.parseExpression(precedenceLowest)
+	if err != nil {
+		return nil, err
+	}
+	return &FuncDefNode{span: span{name.pos, body.End()}, Name: name.value, Params: params, Body: body}, nil
+}
+
+// parseExpression parses operands joined by operators that bind tighter than precedence.
+func (p *parser) parseExpression(precedence int) (Node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if p.isPercent(tok) {
+			// a postfix operator binds tighter than any infix one
+			p.next()
+			left = &PercentNode{span: span{left.Pos(), tok.end()}, Operand: left}
+			continue
+		}
+
+		op, opPrecedence := p.infixOperator(tok)
+		if opPrecedence <= precedence {
+			return left, nil
+		}
+
+		if err := p.checkMode(op); err != nil {
+			return nil, p.errorAt(tok, err)
+		}
+		if _, ok := left.(*PercentNode); op == percentOf && !ok {
+			return nil, p.errorAt(tok, ErrPercentOf)
+		}
+
+		// an implicit multiplication has no token of its own to consume
+		opEnd := tok.pos
+		if tok.kind == tokenOperator {
+			opEnd = p.next().end()
+		}
+		if op == asPercentOf {
+			// "as" must be followed by the rest of "as % of"
+			if !p.isOperator(p.next(), percent) {
+				return nil, p.expected(tok, ErrMalformedExp, percent)
+			}
+			of := p.next()
+			if !p.isOperator(of, percentOf) {
+				return nil, p.expected(tok, ErrMalformedExp, percentOf)
+			}
+			opEnd = of.end()
+		}
+		if op == question {
+			left, err = p.parseConditional(left, tok)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// exponentiation is right-associative, every other operator is left-associative
+		rightPrecedence := opPrecedence
+		if op == power {
+			rightPrecedence--
+		}
+
+		right, err := p.parseExpression(rightPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &BinaryNode{span: span{left.Pos(), right.End()}, Op: op, Left: left, Right: right, OpPos: tok.pos, OpEnd: opEnd}
+	}
+}
+
+// parseOperand parses an operand followed by any number of indices and factorial signs, as in xs[0], m[1][0] or n!.
+// A factorial binds tighter than a prefix operator or an exponentiation, so -3! is -(3!) and 2^3! is 2^(3!).
+// The lexer reads "!=" as the inequality before a factorial sign, so 4!=24 is 4 != 24 rather than 4! = 24.
+func (p *parser) parseOperand() (Node, error) {
+	operand, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch tok := p.peek(); {
+		case tok.kind == tokenOpenBracket:
+			operand, err = p.parseIndex(operand, p.next())
+			if err != nil {
+				return nil, err
+			}
+		case p.isOperator(tok, factorialSign):
+			p.next()
+			operand = &FactorialNode{span: span{operand.Pos(), tok.end()}, Operand: operand}
+		default:
+			return operand, nil
+		}
+	}
+}
+
+// parseIndex parses the index of operand following the opening bracket.
+func (p *parser) parseIndex(operand Node, opening token) (Node, error) {
+	p.depth++
+	if p.depth > p.maxDepth {
+		return nil, p.errorAt(opening, ErrDepthExceeded)
+	}
+
+	index, err := p.parseExpression(precedenceLowest)
+	if err != nil {
+		return nil, err
+	}
+
+	switch closing := p.next(); closing.kind {
+	case tokenCloseBracket:
+		p.depth--
+		return &IndexNode{span: span{operand.Pos(), closing.end()}, Operand: operand, Index: index}, nil
+	case tokenEOF:
+		// more "[" than "]"
+		return nil, p.expected(opening, ErrMalformedExp, "]")
+	default:
+		// an index is a single expression
+		return nil, p.expected(closing, ErrMalformedExp, "]")
+	}
+}
+
+// parsePrimary parses a number, a string, a list, a name, a parenthesized group, a function call,
+// or an operand negated or complemented by a prefix operator.
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		value, err := numberValue(tok.value)
+		if err == ErrOverflow && p.exact {
+			// the literal is only too large for its float64 approximation
+			value, err = math.Inf(1), nil
+		}
+		if err != nil {
+			return nil, p.errorAt(tok, err)
+		}
+		return &NumberNode{span: span{tok.pos, tok.end()}, Value: value}, nil
+
+	case tokenOpenParen:
+		if p.peek().kind == tokenCloseParen {
+			return nil, newSyntaxError(p.expr, ErrEmptyParentheses, tok.pos, p.peek().end())
+		}
+
+		exprs, closing, err := p.parseDelimited(tok, tokenCloseParen)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(exprs) > 1 {
+			// argument lists are only allowed in function calls
+			return nil, p.errorAt(tok, ErrMalformedExp)
+		}
+		return &GroupNode{span: span{tok.pos, closing.end()}, Expr: exprs[0]}, nil
+
+	case tokenIdentifier:
+		if tok.value == trueName || tok.value == falseName {
+			return &BoolNode{span: span{tok.pos, tok.end()}, Value: tok.value == trueName}, nil
+		}
+
+		// a name is only a function call if it is directly followed by its arguments
+		if p.peek().kind != tokenOpenParen {
+			return &IdentNode{span: span{tok.pos, tok.end()}, Name: tok.value}, nil
+		}
+
+		args, closing, err := p.parseDelimited(p.next(), tokenCloseParen)
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.value == ifName {
+			// if(cond, then, else) is a conditional rather than a call, so that only one branch is evaluated
+			if len(args) != 3 {
+				err := fmt.Errorf("%w: %s expects 3", ErrArgumentCount, ifName)
+				return nil, newSyntaxError(p.expr, err, tok.pos, closing.end())
+			}
+			return &ConditionalNode{span: span{tok.pos, closing.end()}, Cond: args[0], Then: args[1], Else: args[2]}, nil
+		}
+		return &CallNode{span: span{tok.pos, closing.end()}, Name: tok.value, Args: args}, nil
+
+	case tokenOpenBracket:
+		elements, closing, err := p.parseDelimited(tok, tokenCloseBracket)
+		if err != nil {
+			return nil, err
+		}
+		return &ListNode{span: span{tok.pos, closing.end()}, Elements: elements}, nil
+
+	case tokenString:
+		text, err := strconv.Unquote(tok.value)
+		if err != nil {
+			// an escape sequence which doesn't exist
+			return nil, p.errorAt(tok, ErrMalformedExp)
+		}
+		return &StringNode{span: span{tok.pos, tok.end()}, Value: text}, nil
+
+	case tokenCurrency:
+		return p.parseAmount(tok)
+
+	case tokenOperator:
+		// a negation, complement or logical not cannot be directly followed by another of the same
+		if p.isOperator(tok, subtract, bitNot, not) && !p.isOperator(p.peek(), tok.value) {
+			if err := p.checkMode(tok.value); err != nil {
+				return nil, p.errorAt(tok, err)
+			}
+
+			operand, err := p.parseExpression(precedenceUnary)
+			if err != nil {
+				return nil, err
+			}
+			if pct, ok := operand.(*PercentNode); ok && tok.value == subtract {
+				// a negated percentage is a percentage of the negation, so that 200 + -10% is 180
+				negation := &UnaryNode{span: span{tok.pos, pct.Operand.End()}, Op: subtract, Operand: pct.Operand}
+				return &PercentNode{span: span{tok.pos, operand.End()}, Operand: negation}, nil
+			}
+			return &UnaryNode{span: span{tok.pos, operand.End()}, Op: tok.value, Operand: operand}, nil
+		}
+	}
+
+	return nil, p.unexpected(tok)
+}
+
+// parseConditional parses the branches of a conditional whose condition cond is followed by the "?" token,
+// such as "x > 0 ? x : -x". Conditionals are right-associative, so "a ? b : c ? d : e" is "a ? b : (c ? d : e)".
+func (p *parser) parseConditional(cond Node, question token) (Node, error) {
+	then, err := p.parseExpression(precedenceLowest)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isOperator(p.next(), colon) {
+		return nil, p.expected(question, ErrMissingColon, colon)
+	}
+
+	otherwise, err := p.parseExpression(precedenceConditional - 1)
+	if err != nil {
+		return nil, err
+	}
+	return &ConditionalNode{span: span{cond.Pos(), otherwise.End()}, Cond: cond, Then: then, Else: otherwise}, nil
+}
+
+// parseAmount parses a number preceded by the currency symbol tok, such as $12.50,
+// which is the product of the number and the code of the currency, as in 12.50 USD.
+func (p *parser) parseAmount(symbol token) (Node, error) {
+	if !p.currency {
+		return nil, p.errorAt(symbol, ErrCurrencySymbol)
+	}
+	if p.peek().kind != tokenNumber {
+		return nil, p.unexpected(p.peek())
+	}
+
+	amount, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	code := &IdentNode{span: span{symbol.pos, symbol.end()}, Name: currencySymbols[symbol.value]}
+	return &BinaryNode{span: span{symbol.pos, amount.End()}, Op: multiply, Left: amount, Right: code, OpPos: symbol.pos, OpEnd: symbol.pos}, nil
+}
+
+// numberValue returns the float64 closest to the value of a number literal.
+func numberValue(text string) (float64, error) {
+	if len(text) > 2 && radixPrefixes[text[:2]] != 0 {
+		n, ok := new(big.Int).SetString(text, 0)
+		if !ok {
+			return 0, ErrMalformedExp
+		}
+
+		value, _ := new(big.Float).SetInt(n).Float64()
+		if math.IsInf(value, 0) {
+			return 0, ErrOverflow
+		}
+		return value, nil
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, ErrOverflow
+	}
+	if err != nil {
+		return 0, ErrMalformedExp
+	}
+	return value, nil
+}
+
+// closings names the tokens which close delimited lists.
+var closings = map[tokenKind]string{tokenCloseParen: ")", tokenCloseBracket: "]"}
+
+// parseDelimited parses the comma-separated expressions following an opening parenthesis or bracket
+// up to the closing token of the given kind, which is returned alongside them.
+func (p *parser) parseDelimited(opening token, closingKind tokenKind) ([]Node, token, error) {
+	p.depth++
+	if p.depth > p.maxDepth {
+		return nil, token{}, p.errorAt(opening, ErrDepthExceeded)
+	}
+
+	var exprs []Node
+	for p.peek().kind != closingKind || len(exprs) > 0 {
+		expr, err := p.parseExpression(precedenceLowest)
+		if err != nil {
+			return nil, token{}, err
+		}
+		exprs = append(exprs, expr)
+
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+
+	closing := p.next()
+	switch closing.kind {
+	case closingKind:
+	case tokenEOF:
+		// more "(" than ")", or "[" than "]"
+		return nil, token{}, p.expected(opening, ErrMalformedExp, closings[closingKind])
+	default:
+		return nil, token{}, p.unexpected(closing)
+	}
+
+	p.depth--
+	return exprs, closing, nil
+}
+
+// unexpected returns the error describing a token found where it isn't allowed.
+func (p *parser) unexpected(tok token) error {
+	switch following := p.following(tok); {
+	case tok.kind == tokenCloseParen, tok.kind == tokenCloseBracket:
+		// more ")" than "(", or "]" than "["
+		return p.errorAt(tok, ErrMalformedExp)
+	case tok.kind == tokenNumber, tok.kind == tokenIdentifier, tok.kind == tokenCurrency, tok.kind == tokenString:
+		// consecutive operands without an operator between them
+		return p.errorAt(tok, ErrMalformedExp)
+	case tok.kind == tokenComma:
+		// a comma outside of an argument list
+		return p.errorAt(tok, ErrMalformedExp)
+	case p.isOperator(tok, colon):
+		// a colon outside of a conditional
+		return p.errorAt(tok, ErrMalformedExp)
+	case tok.kind == tokenSemicolon && p.preceding(tok).kind == tokenOperator,
+		tok.kind == tokenSemicolon && p.preceding(tok).kind == tokenAssign:
+		// a statement ending with a dangling operator
+		return p.errorAt(p.preceding(tok), ErrIllegalEnd)
+	case tok.kind == tokenAssign, tok.kind == tokenSemicolon:
+		// an assignment to something other than a name, or an empty statement
+		return p.errorAt(tok, ErrMalformedExp)
+	case tok.kind == tokenEOF && tok == p.tokens[0]:
+		// nothing but whitespace, if anything, was read
+		return p.errorAt(tok, ErrIllegalStart)
+	case tok.kind == tokenEOF:
+		// point at the dangling operator rather than past the end
+		return p.errorAt(p.preceding(tok), ErrIllegalEnd)
+	case tok == p.tokens[0] && p.isOperator(tok, subtract, bitNot, not) && p.isOperator(following, tok.value):
+		// a doubled prefix operator, such as "--1", may begin an expression but not repeat
+		return p.errorAt(following, ErrIllegalConsecutiveOperator)
+	case tok == p.tokens[0]:
+		return p.errorAt(tok, ErrIllegalStart)
+	case tok.kind == tokenOperator && tok.value == decimal && following.kind == tokenEOF:
+		return p.errorAt(tok, ErrIllegalEnd)
+	default:
+		return p.errorAt(tok, ErrIllegalConsecutiveOperator)
+	}
+}
+
+// errorAt returns a SyntaxError spanning the given token.
+func (p *parser) errorAt(tok token, err error) *SyntaxError {
+	return newSyntaxError(p.expr, err, tok.pos, tok.end())
+}
+
+// expected returns the error for a token found where only the token want is allowed, or for the token
+// whose counterpart want is missing, such as an unclosed "(".
+func (p *parser) expected(tok token, err error, want string) *SyntaxError {
+	syntaxErr := p.errorAt(tok, err)
+	syntaxErr.Expected = want
+	return syntaxErr
+}
+
+// following returns the token after tok.
+func (p *parser) following(tok token) token {
+	for i, t := range p.tokens {
+		if t.pos > tok.pos {
+			return p.tokens[i]
+		}
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+// preceding returns the token before tok, or tok itself if it is the first.
+func (p *parser) preceding(tok token) token {
+	for i := len(p.tokens) - 1; i > 0; i-- {
+		if p.tokens[i].pos < tok.pos {
+			return p.tokens[i]
+		}
+	}
+	return p.tokens[0]
+}
+
+// isOperator reports whether tok is any of the given operators.
+func (p *parser) isOperator(tok token, ops ...string) bool {
+	if tok.kind != tokenOperator {
+		return false
+	}
+
+	for _, op := range ops {
+		if tok.value == op {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMode returns the error of an operator which is only allowed in a mode other than the parser's.
+func (p *parser) checkMode(op string) error {
+	switch err := modeOperators[op]; {
+	case err == ErrIntegerOperator && !p.integer, err == ErrUnitOperator && !p.units && !p.currency:
+		return err
+	}
+	return nil
+}
+
+// isPercent reports whether tok is a postfix percent rather than a modulus, which is the case
+// when the token after it can't start an operand, as in "10%", "10% + 1" or "10% of 80".
+// A sign after "%" is only an addition or subtraction if the "%" is written against its operand,
+// so "10% - 5" is "(10%) - 5" while "7 % -2" is still the modulus of 7 by -2.
+func (p *parser) isPercent(tok token) bool {
+	if !p.isOperator(tok, percent) {
+		return false
+	}
+
+	switch following := p.following(tok); following.kind {
+	case tokenNumber, tokenIdentifier, tokenOpenParen, tokenCurrency, tokenString, tokenOpenBracket:
+		return false
+	default:
+		if p.isOperator(following, add, subtract) {
+			return p.preceding(tok).end() == tok.pos
+		}
+		return !p.isOperator(following, bitNot, not)
+	}
+}
+
+// infixOperator returns the operator and binding power of a token in infix position.
+// Tokens that cannot continue an expression have the lowest binding power.
+//
+// An operand directly followed by another is an implicit multiplication if the first is
+// a number or ends with ")", and the second is a name, a function call or starts with "(",
+// as in "2pi", "3(e+1)", "2sqrt(2)" or "(1+2)(3+4)". As it has the same binding power as
+// an explicit multiplication, "1/2pi" is "(1/2)*pi". Operands are never implicitly
+// multiplied when the second one is a number, or when both are names, as in "pi e",
+// which is more likely to be a mistyped name.
+//
+// In units and currency mode, a number directly followed by a name binds tighter than an explicit
+// multiplication, so that a quantity such as "5 m" is a single operand of "10 m / 5 m".
+func (p *parser) infixOperator(tok token) (string, int) {
+	switch tok.kind {
+	case tokenOpenParen, tokenIdentifier:
+		switch p.tokens[p.pos-1].kind {
+		case tokenNumber:
+			if (p.units || p.currency) && tok.kind == tokenIdentifier {
+				return multiply, precedenceQuantity
+			}
+			return multiply, precedenceMultiplicative
+		case tokenCloseParen:
+			return multiply, precedenceMultiplicative
+		}
+
+	case tokenOperator:
+		switch tok.value {
+		case add, subtract:
+			return tok.value, precedenceAdditive
+		case multiply, multiplyElements, divide, floorDivide, modulus:
+			return tok.value, precedenceMultiplicative
+		case power, powerAlias:
+			return power, precedencePower
+		case bitOr:
+			return tok.value, precedenceBitOr
+		case bitXor:
+			return tok.value, precedenceBitXor
+		case bitAnd:
+			return tok.value, precedenceBitAnd
+		case shiftLeft, shiftRight:
+			return tok.value, precedenceShift
+		case convertTo, convertIn:
+			return convertTo, precedenceConversion
+		case less, lessOrEqual, equal, notEqual, greaterOrEqual, greater:
+			return tok.value, precedenceComparison
+		case listRange:
+			return tok.value, precedenceRange
+		case and:
+			return tok.value, precedenceAnd
+		case or:
+			return tok.value, precedenceOr
+		case question:
+			return tok.value, precedenceConditional
+		case percentOf:
+			return percentOf, precedenceMultiplicative
+		case asPercent:
+			return asPercentOf, precedenceConversion
+		}
+	}
+
+	return "", precedenceLowest
+}
@@ -0,0 +1,198 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_maxDepth(t *testing.T) {
+	_, err := Parse("((1))", WithMaxDepth(2))
+	if err != nil {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, nil)
+	}
+
+	_, err = Parse("(((1)))", WithMaxDepth(2))
+	if !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrDepthExceeded)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+		wantErr    error
+	}{
+		{"single operand", "4", "4", nil},
+		{"decimal operand", "3.54", "3.54", nil},
+		{"addition", "2 + 5", "(2 + 5)", nil},
+		{"precedence", "2+5*3", "(2 + (5 * 3))", nil},
+		{"left associativity", "8-4-2", "((8 - 4) - 2)", nil},
+		{"negation", "-2*3", "((-2) * 3)", nil},
+		{"negated operand after operator", "4*-2", "(4 * (-2))", nil},
+		{"group", "(2+5)*3", "(((2 + 5)) * 3)", nil},
+		{"implicit multiplication", "2(6+4)", "(2 * ((6 + 4)))", nil},
+		{"adjacent groups", "(1)(2)", "((1) * (2))", nil},
+		{"exponentiation", "2^3^2", "(2 ^ (3 ^ 2))", nil},
+		{"exponentiation alias", "2**3", "(2 ^ 3)", nil},
+		{"negated exponentiation", "-2^2", "(-(2 ^ 2))", nil},
+		{"modulus and floor division", "7%3//2", "((7 % 3) // 2)", nil},
+		{"nested groups", "2(3(4+1))", "(2 * ((3 * ((4 + 1)))))", nil},
+		{"empty expression", "", "", ErrIllegalStart},
+		{"illegal character", "3 + #", "", ErrIllegalCharacter},
+		{"function call", "2 + sqrt(9)", "(2 + sqrt(9))", nil},
+		{"function call with arguments", "max(1, 2+3, -4)", "max(1, (2 + 3), (-4))", nil},
+		{"function call without arguments", "f()", "f()", nil},
+		{"constant", "2 * pi", "(2 * pi)", nil},
+		{"implicit multiplication of constant", "2pi", "(2 * pi)", nil},
+		{"implicit multiplication of constant after group", "(1+1)pi", "(((1 + 1)) * pi)", nil},
+		{"implicit multiplication of call", "2sqrt(2)", "(2 * sqrt(2))", nil},
+		{"implicit multiplication after call", "sqrt(2)pi", "(sqrt(2) * pi)", nil},
+		{"implicit multiplication precedence", "1/2pi", "((1 / 2) * pi)", nil},
+		{"implicit multiplication before exponentiation", "2pi^2", "(2 * (pi ^ 2))", nil},
+		{"name followed by group", "e(1)", "e(1)", nil},
+		{"consecutive names", "pi e", "", ErrMalformedExp},
+		{"number after name", "pi 2", "", ErrMalformedExp},
+		{"number after group", "(1)2", "", ErrMalformedExp},
+		{"assignment", "x = 2 + 3", "x = (2 + 3)", nil},
+		{"program", "x = 3; y = x^2 + 1; y / 2", "x = 3; y = ((x ^ 2) + 1); (y / 2)", nil},
+		{"trailing semicolon", "x = 3;", "x = 3", nil},
+		{"empty statement", "x = 3;; x", "", ErrMalformedExp},
+		{"leading semicolon", "; x", "", ErrMalformedExp},
+		{"chained assignment", "x = y = 3", "", ErrMalformedExp},
+		{"assignment to expression", "2x = 3", "", ErrMalformedExp},
+		{"assignment without value", "x =", "", ErrIllegalEnd},
+		{"function definition", "f(x, y) = x^2 + y", "f(x, y) = ((x ^ 2) + y)", nil},
+		{"function definition without parameters", "g() = 2pi", "g() = (2 * pi)", nil},
+		{"function definition in program", "f(x) = 2x; f(3)", "f(x) = (2 * x); f(3)", nil},
+		{"repeated parameter", "f(x, x) = x", "", ErrMalformedExp},
+		{"expression as parameter", "f(x + 1) = x", "", ErrMalformedExp},
+		{"nested function calls", "max(1, min(2, 3))^2", "(max(1, min(2, 3)) ^ 2)", nil},
+		{"illegal start", "*2", "", ErrIllegalStart},
+		{"illegal end", "2+", "", ErrIllegalEnd},
+		{"trailing decimal", "2.", "", ErrIllegalEnd},
+		{"consecutive operators", "2*/3", "", ErrIllegalConsecutiveOperator},
+		{"triple minus", "4---2", "", ErrIllegalConsecutiveOperator},
+		{"empty parentheses", "2()", "", ErrEmptyParentheses},
+		{"unclosed parenthesis", "(2+3", "", ErrMalformedExp},
+		{"unopened parenthesis", "2+3)", "", ErrMalformedExp},
+		{"consecutive operands", "2 3", "", ErrMalformedExp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.expression)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+
+			if err == nil && node.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", node, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_syntaxError(t *testing.T) {
+	tests := []struct {
+		name          string
+		expression    string
+		wantErr       error
+		wantOffset    int
+		wantLength    int
+		wantStatement int
+	}{
+		{"illegal character", "2 + #", ErrIllegalCharacter, 4, 1, 0},
+		{"malformed number", "2 + 1_000_", ErrMalformedNumber, 4, 6, 0},
+		{"consecutive names", "2 + pi e", ErrMalformedExp, 7, 1, 0},
+		{"unexpected operator", "2 * / 3", ErrIllegalConsecutiveOperator, 4, 1, 0},
+		{"unexpected start", "*2", ErrIllegalStart, 0, 1, 0},
+		{"only whitespace", "   ", ErrIllegalStart, 3, 0, 0},
+		{"doubled negation", "--1", ErrIllegalConsecutiveOperator, 1, 1, 0},
+		{"unexpected end", "2 * ", ErrIllegalEnd, 2, 1, 0},
+		{"empty parentheses", "2 * ( )", ErrEmptyParentheses, 4, 3, 0},
+		{"unclosed parenthesis", "2 * (3", ErrMalformedExp, 4, 1, 0},
+		{"error in first statement", "x = 2 *; x", ErrIllegalEnd, 6, 1, 1},
+		{"error in later statement", "x = 2; y = x */ 2; y", ErrIllegalConsecutiveOperator, 14, 1, 2},
+		{"illegal character in later statement", "x = 2; y = #", ErrIllegalCharacter, 11, 1, 2},
+		{"illegal character before later statement", "x = #; y = 2", ErrIllegalCharacter, 4, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("\nGot:\t%#v\nWant:\t*SyntaxError", err)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+
+			if syntaxErr.Offset != tt.wantOffset || syntaxErr.Length != tt.wantLength || syntaxErr.Statement != tt.wantStatement {
+				t.Errorf("\nGot:\toffset %d, length %d, statement %d\nWant:\toffset %d, length %d, statement %d",
+					syntaxErr.Offset, syntaxErr.Length, syntaxErr.Statement, tt.wantOffset, tt.wantLength, tt.wantStatement)
+			}
+		})
+	}
+}
+
+func TestParse_expectedToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		wantExpected string
+		wantMsg      string
+	}{
+		{"unclosed call", "max(1, 2", ")", `malformed expression: "(" at column 4, expected ")"`},
+		{"unclosed list", "[1, 2", "]", `malformed expression: "[" at column 1, expected "]"`},
+		{"unclosed index", "xs[1", "]", `malformed expression: "[" at column 3, expected "]"`},
+		{"two indices", "xs[1, 2]", "]", `malformed expression: "," at column 5, expected "]"`},
+		{"missing colon", "x ? 1", ":", `'?' must be followed by ':': "?" at column 3, expected ":"`},
+		{"unfinished as percent of", "1 as 2", "%", `malformed expression: "as" at column 3, expected "%"`},
+		{"parameters without comma", "f(a b) = 1", ")", `malformed expression: "b" at column 5, expected ")"`},
+		{"consecutive operands", "2 3", "", `malformed expression: "3" at column 3`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("\nGot:\t%#v\nWant:\t*SyntaxError", err)
+			}
+
+			if syntaxErr.Expected != tt.wantExpected || err.Error() != tt.wantMsg {
+				t.Errorf("\nGot:\t%q, %s\nWant:\t%q, %s", syntaxErr.Expected, err, tt.wantExpected, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParse_edgeMessages(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantMsg    string
+	}{
+		{"*2", `expression cannot begin with "*" at column 1`},
+		{"] + 1", `malformed expression: "]" at column 1`},
+		{"2 ^", `expression cannot end with "^" at column 3`},
+		{"x = 5% of", `expression cannot end with "of" at column 8`},
+		{"", `expression is empty at column 1`},
+		{"   ", `expression is empty at column 4`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+			if err == nil || err.Error() != tt.wantMsg {
+				t.Errorf("\nGot:\t%v\nWant:\t%s", err, tt.wantMsg)
+			}
+		})
+	}
+}
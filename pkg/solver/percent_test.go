@@ -0,0 +1,195 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_percentages(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"percentage", "10%", "(10%)"},
+		{"percentage of group", "(1 + 2)%", "(((1 + 2))%)"},
+		{"percentage of name", "x%", "(x%)"},
+		{"increase", "200 + 10%", "(200 + (10%))"},
+		{"percentage binds tightest", "2^10%", "(2 ^ (10%))"},
+		{"negated percentage", "-5%", "((-5)%)"},
+		{"percentage of number", "50% of 80", "((50%) of 80)"},
+		{"of binds like multiplication", "1 + 50% of 80 * 2", "(1 + (((50%) of 80) * 2))"},
+		{"percentage as percent of number", "20 as % of 80", "(20 as % of 80)"},
+		{"as percent of binds loosest", "10 + 10 as % of 40 * 2", "((10 + 10) as % of (40 * 2))"},
+		{"modulus before number", "7 % 3", "(7 % 3)"},
+		{"modulus before name", "7 % x", "(7 % x)"},
+		{"modulus before group", "7 % (-2)", "(7 % ((-2)))"},
+		{"modulus before minus", "7 % -2", "(7 % (-2))"},
+		{"percentage before minus", "10% -5", "((10%) - 5)"},
+		{"percentage before plus", "10%+5", "((10%) + 5)"},
+		{"percentage before operator", "4%*2", "((4%) * 2)"},
+		{"percentages", "10%%", "((10%)%)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_percentageErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+		wantOffset int
+		wantLength int
+	}{
+		{"of without percentage", "5 of 80", ErrPercentOf, 2, 2},
+		{"of after group of percentage", "(5%) of 80", ErrPercentOf, 5, 2},
+		{"as without percent of", "20 as 80", ErrMalformedExp, 3, 2},
+		{"as percent without of", "20 as % 80", ErrMalformedExp, 3, 2},
+		{"dangling of", "5% of", ErrIllegalEnd, 3, 2},
+		{"percent after operator", "4*%2", ErrIllegalConsecutiveOperator, 2, 1},
+		{"percent at start", "%2", ErrIllegalStart, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+			if syntaxErr.Offset != tt.wantOffset || syntaxErr.Length != tt.wantLength {
+				t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", syntaxErr.Offset, syntaxErr.Length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestSolve_percentages(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantResult string
+	}{
+		{"percentage", "10%", "0.1"},
+		{"increase", "200 + 10%", "220"},
+		{"decrease", "200 - 10%", "180"},
+		{"percentage of product", "80 * 25%", "20"},
+		{"whole of percentage", "20 / 25%", "80"},
+		{"percentage of number", "50% of 80", "40"},
+		{"percentage as percent of number", "20 as % of 80", "25%"},
+		{"percentage over a hundred", "120 as % of 80", "150%"},
+		{"negative percentage", "200 + -10%", "180"},
+		{"percentage of group", "(150 + 50) * (5 + 5)%", "20"},
+		{"chained increases", "100 + 10% + 10%", "121"},
+		{"percentage in sum", "7% + 3", "3.07"},
+		{"percentage before minus", "7% - 2", "-1.93"},
+		{"percentage of percentage", "50% of 50%", "0.25"},
+		{"percentage not whole operand", "200 + 10% * 2", "200.2"},
+		{"modulus", "7 % 3", "1"},
+		{"modulus by negative", "7 % (-2)", "1"},
+		{"variables", "price = 80; discount = 25; price - discount%", "60"},
+		{"group of as percent of", "(1 as % of 4)", "25%"},
+		{"as percent of in sum", "(1 as % of 4) + 1", "26"},
+		{"as percent of assigned", "x = 1 as % of 4", "25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestSolve_percentagesInModes(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantResult string
+	}{
+		{"precision", "0.1 + 10%", []Option{WithPrecision(34)}, "0.11"},
+		{"rational", "1/3 + 50%", []Option{WithRational(Fraction, RejectInexact)}, "1/2"},
+		{"rational as percent of", "1 as % of 3", []Option{WithRational(Fraction, RejectInexact)}, "100/3%"},
+		{"integer", "200 + 15%", []Option{WithInteger()}, "230"},
+		{"complex", "(2 + 4i) * 50%", []Option{WithComplex()}, "1+2i"},
+		{"units", "3 km + 10%", []Option{WithUnits()}, "3.3 km"},
+		{"units as percent of", "250 m as % of 1 km", []Option{WithUnits()}, "25%"},
+		{"currency", "$200 - 15%", []Option{WithCurrency(StaticRates{"USD": 1})}, "170.00 USD"},
+		{"currency percentage of", "8% of €12.50", []Option{WithCurrency(StaticRates{"EUR": 1})}, "1.00 EUR"},
+		{"currency as percent of", "$5 as % of $20", []Option{WithCurrency(StaticRates{"USD": 1})}, "25%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_percentageErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+	}{
+		{"whole of zero percent", "20 / 0%", nil, ErrDivisionByZero},
+		{"as percent of zero", "20 as % of 0", nil, ErrDivisionByZero},
+		{"integer percentage", "10%", []Option{WithInteger()}, ErrNotInteger},
+		{"inexact integer increase", "201 + 10%", []Option{WithInteger()}, ErrNotInteger},
+		{"percentage of different currencies", "$5 as % of 20 EUR", []Option{WithCurrency(StaticRates{"USD": 1, "EUR": 1})}, ErrCurrency},
+		{"increase of different dimensions", "1 m + 1 s%", []Option{WithUnits()}, ErrDimension},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResult_percent(t *testing.T) {
+	result, err := Eval("51 as % of 204")
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	// the value is the number of percent, which is only written followed by "%"
+	if result.Float() != 25 {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", result.Float(), 25)
+	}
+	if text, err := result.Text(16); err != nil || text != "0x19%" {
+		t.Errorf("\nGot:\t%s, %v\nWant:\t%s, %v", text, err, "0x19%", nil)
+	}
+}
@@ -0,0 +1,95 @@
+package solver
+
+import "strings"
+
+// Program is a compiled expression which can be evaluated many times without being parsed again.
+// A Program is immutable and may be evaluated by several goroutines at once,
+// provided they don't share an Env that the program assigns to.
+type Program struct {
+	expr string
+	node Node
+	// code is the program compiled for the virtual machine, which is nil if it is evaluated in a number system.
+	code *chunk
+	// literals holds the numbers of the expression converted to the number system of cfg, if it has one.
+	literals literals
+	cfg      *config
+}
+
+// Compile validates an expression and prepares it for evaluation.
+// A *SyntaxError is returned if the expression is malformed.
+// The options apply to every evaluation of the returned program.
+func Compile(expr string, opts ...Option) (*Program, error) {
+	cfg := newConfig(opts)
+
+	node, err := parse(expr, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Program{expr: expr, node: node, cfg: cfg}
+	if cfg.system != nil {
+		// the program is evaluated in its number system rather than by the virtual machine
+		p.literals = convertLiterals(cfg.system, expr, node)
+	} else {
+		p.code = compile(expr, node)
+	}
+	return p, nil
+}
+
+// Eval computes the value of the program, resolving names from env as EvalWithEnv does.
+// Assignments and function definitions are stored in env, which may be nil.
+// Programs which only do arithmetic with numbers and variables evaluate without allocating memory,
+// unless they are compiled with an option which changes how numbers are represented, such as WithPrecision,
+// WithRational, WithInteger, WithComplex, WithUnits or WithCurrency. Such programs convert the numbers
+// they are written with once when compiled, and evaluate with the numbers of their mode.
+func (p *Program) Eval(env *Env) (Result, error) {
+	if p.cfg.system != nil {
+		exact, approximations, err := evaluateIn(p.cfg.system, p.expr, p.node, p.literals, env, p.cfg)
+		if err != nil {
+			return Result{}, locateEvalStatement(err.(*EvalError), p.node)
+		}
+
+		result := Result{exact: exact, system: p.cfg.system, percent: isPercentage(p.node)}
+		if v, ok := exact.(Value); ok {
+			result.value, result.ref = approximate(v), v
+		} else {
+			result.value = p.cfg.system.float(exact)
+		}
+		for _, approximation := range approximations {
+			result.warnings = append(result.warnings, locateEvalStatement(approximation, p.node).Error())
+		}
+		return result, nil
+	}
+
+	v, err := execute(p.code, env, p.cfg)
+	if err != nil {
+		return Result{}, locateEvalStatement(err.(*EvalError), p.node)
+	}
+	result := Result{value: v.num, ref: v.ref, percent: isPercentage(p.node)}
+	if v.ref != nil {
+		result.value = approximate(v.ref)
+	}
+	return result, nil
+}
+
+// noBytecode is the listing of a program which isn't compiled to instructions.
+const noBytecode = "no bytecode: the program is evaluated in the number system of its options\n"
+
+// Disassemble returns a listing of the instructions the program was compiled to,
+// followed by those of each function it defines. It is meant for debugging.
+// Programs compiled with an option which changes how numbers are represented, such as WithPrecision,
+// aren't run by the virtual machine and have no instructions to list.
+func (p *Program) Disassemble() string {
+	if p.code == nil {
+		return noBytecode
+	}
+
+	var b strings.Builder
+	p.code.disassemble(&b)
+	return b.String()
+}
+
+// String returns the expression the program was compiled from.
+func (p *Program) String() string {
+	return p.expr
+}
@@ -0,0 +1,126 @@
+package solver
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	program, err := Compile("rate * hours + fee")
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	tests := []struct {
+		name       string
+		vars       map[string]float64
+		wantResult string
+	}{
+		{"first row", map[string]float64{"rate": 12.5, "hours": 8, "fee": 20}, "120"},
+		{"second row", map[string]float64{"rate": 10, "hours": 4, "fee": 0}, "40"},
+		{"third row", map[string]float64{"rate": 0.5, "hours": 3, "fee": 1.5}, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := program.Eval(NewEnv(tt.vars))
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.String() != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+
+	if _, err := program.Eval(nil); !errors.Is(err, ErrUndefinedName) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrUndefinedName)
+	}
+}
+
+func TestCompile_syntaxError(t *testing.T) {
+	_, err := Compile("2 * / 3")
+
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || !errors.Is(err, ErrIllegalConsecutiveOperator) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrIllegalConsecutiveOperator)
+	}
+}
+
+func TestCompile_options(t *testing.T) {
+	if _, err := Compile("((1))", WithMaxDepth(1)); !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrDepthExceeded)
+	}
+
+	program, err := Compile("f(x) = f(x); f(1)", WithMaxCallDepth(10))
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	if _, err := program.Eval(nil); !errors.Is(err, ErrCallDepthExceeded) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrCallDepthExceeded)
+	}
+}
+
+func TestProgram_Eval_concurrent(t *testing.T) {
+	program, err := Compile("y = x^2; sq(a) = a * a; sq(y) + 1")
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(x float64) {
+			defer wg.Done()
+
+			// each goroutine has an environment of its own
+			result, err := program.Eval(NewEnv(map[string]float64{"x": x}))
+			if err != nil {
+				t.Errorf("\nUnexpected error:\t%v", err)
+				return
+			}
+
+			if want := x*x*x*x + 1; result.Float() != want {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", result.Float(), want)
+			}
+		}(float64(i))
+	}
+	wg.Wait()
+}
+
+func TestCompile_literals(t *testing.T) {
+	program, err := Compile("sq(a) = a * a; sq(x / 3) + 0.1", WithRational(Fraction, RejectInexact))
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	// every number written in the program, including those of the functions it defines, is converted once
+	if len(program.literals) != 2 {
+		t.Errorf("\nGot:\t%d literals\nWant:\t%d literals", len(program.literals), 2)
+	}
+
+	// the converted numbers aren't changed by evaluating with them
+	for _, x := range []float64{1, 2, 1} {
+		result, err := program.Eval(NewEnv(map[string]float64{"x": x}))
+		if err != nil {
+			t.Fatalf("\nUnexpected error:\t%v", err)
+		}
+
+		want := map[float64]string{1: "19/90", 2: "49/90"}[x]
+		if result.String() != want {
+			t.Errorf("\nGot:\t%s\nWant:\t%s", result, want)
+		}
+	}
+
+	// numbers which can't be converted are reported by Eval, as they are without compiling
+	program, err = Compile("1.5 + 1", WithInteger())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if _, err := program.Eval(nil); !errors.Is(err, ErrNotInteger) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrNotInteger)
+	}
+}
@@ -0,0 +1,105 @@
+package solver
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// StaticRates is a RateProvider of fixed exchange rates, which needs no network access.
+// It maps the code of each currency to its amount worth one unit of a common base currency,
+// whose own rate is 1, so that the rate from USD to EUR is StaticRates["EUR"] / StaticRates["USD"].
+type StaticRates map[string]float64
+
+// Rate returns the amount of the currency to that one unit of the currency from is worth,
+// and fails with ErrUnknownCurrency if either has no rate.
+func (r StaticRates) Rate(from, to string) (float64, error) {
+	for _, code := range []string{from, to} {
+		if _, ok := r[code]; !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+		}
+	}
+	return r[to] / r[from], nil
+}
+
+// LoadRates reads exchange rates from a JSON or CSV file, depending on whether its name ends with .json or .csv.
+//
+// A JSON file holds an object mapping currency codes to rates, such as {"USD": 1, "EUR": 0.92}.
+// A CSV file holds a currency code and a rate on each line, such as "EUR,0.92", and may start with a header line.
+// Rates are the amounts of each currency worth one unit of a common base currency, as in StaticRates.
+func LoadRates(path string) (StaticRates, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return readJSONRates(file)
+	case ".csv":
+		return readCSVRates(file)
+	default:
+		return nil, fmt.Errorf("rates file %s must be .json or .csv", path)
+	}
+}
+
+// readJSONRates reads an object mapping currency codes to rates.
+func readJSONRates(r io.Reader) (StaticRates, error) {
+	var rates StaticRates
+	if err := json.NewDecoder(r).Decode(&rates); err != nil {
+		return nil, fmt.Errorf("invalid rates: %w", err)
+	}
+	if err := checkRates(rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// readCSVRates reads lines of a currency code and a rate, skipping a header line if there is one.
+func readCSVRates(r io.Reader) (StaticRates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates: %w", err)
+	}
+
+	rates := make(StaticRates, len(records))
+	for i, record := range records {
+		rate, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			if i == 0 {
+				// the header names the columns rather than giving a rate
+				continue
+			}
+			return nil, fmt.Errorf("invalid rate of %s on line %d: %q", record[0], i+1, record[1])
+		}
+		rates[record[0]] = rate
+	}
+	if err := checkRates(rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// checkRates reports a currency with an invalid code or a rate which isn't a positive number.
+func checkRates(rates StaticRates) error {
+	for code, rate := range rates {
+		switch {
+		case !isCurrencyCode(code):
+			return fmt.Errorf("invalid currency code %q, expected three capital letters", code)
+		case !(rate > 0) || math.IsInf(rate, 1):
+			return fmt.Errorf("invalid rate of %s: %v", code, rate)
+		}
+	}
+	return nil
+}
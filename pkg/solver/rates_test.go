@@ -0,0 +1,73 @@
+package solver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStaticRates_Rate(t *testing.T) {
+	rates := StaticRates{"USD": 1, "EUR": 0.8, "GBP": 0.5}
+
+	tests := []struct {
+		from, to string
+		wantRate float64
+		wantErr  error
+	}{
+		{"USD", "EUR", 0.8, nil},
+		{"EUR", "USD", 1.25, nil},
+		{"EUR", "GBP", 0.625, nil},
+		{"USD", "CHF", 0, ErrUnknownCurrency},
+		{"CHF", "USD", 0, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"/"+tt.to, func(t *testing.T) {
+			rate, err := rates.Rate(tt.from, tt.to)
+
+			if rate != tt.wantRate || !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", rate, err, tt.wantRate, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRates(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		wantRates StaticRates
+		wantErr   bool
+	}{
+		{"json", "rates.json", `{"USD": 1, "EUR": 0.92}`, StaticRates{"USD": 1, "EUR": 0.92}, false},
+		{"csv", "rates.csv", "USD,1\nEUR, 0.92\n", StaticRates{"USD": 1, "EUR": 0.92}, false},
+		{"csv with header", "rates.CSV", "currency,rate\nGBP,0.79\n", StaticRates{"GBP": 0.79}, false},
+		{"malformed json", "rates.json", `{"USD": "one"}`, nil, true},
+		{"malformed csv", "rates.csv", "USD,1\nEUR,zero\n", nil, true},
+		{"missing column", "rates.csv", "USD\n", nil, true},
+		{"invalid code", "rates.json", `{"usd": 1}`, nil, true},
+		{"negative rate", "rates.csv", "EUR,-1\n", nil, true},
+		{"unknown format", "rates.txt", "USD 1", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			rates, err := LoadRates(path)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("\nGot:\t%v\nWant error:\t%v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(rates, tt.wantRates) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", rates, tt.wantRates)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExactBits bounds the size of exact integers and of the numerators and denominators of exact fractions,
+// beyond which results overflow.
+const maxExactBits = 1 << 24
+
+// maxRepeatingDigits is the most fractional digits written in a decimal expansion
+// before giving up on finding where its digits repeat.
+const maxRepeatingDigits = 100
+
+// rationalSystem evaluates expressions with exact rational numbers, which are *big.Rat values.
+// Results which aren't rational are float64 approximations, if the policy allows them.
+type rationalSystem struct {
+	style   RationalFormat
+	inexact InexactPolicy
+}
+
+func (s rationalSystem) literal(text string) (interface{}, error) {
+	coef, exp, err := parseDecimal(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// a power of ten has a little over 3 bits per digit
+	if exp > maxExactBits/4 || exp < -maxExactBits/4 {
+		return nil, ErrOverflow
+	}
+
+	x := new(big.Rat).SetInt(coef)
+	if exp >= 0 {
+		return x.Mul(x, new(big.Rat).SetInt(pow10(exp))), nil
+	}
+	return x.Quo(x, new(big.Rat).SetInt(pow10(-exp))), nil
+}
+
+// fromFloat converts x by its shortest decimal form rather than its exact binary value,
+// so that a variable of 0.1 is exactly 1/10.
+func (s rationalSystem) fromFloat(x float64) (interface{}, error) {
+	switch {
+	case math.IsNaN(x):
+		return nil, ErrNotANumber
+	case math.IsInf(x, 0):
+		return s.approximate(x)
+	}
+	return s.literal(strconv.FormatFloat(x, 'g', -1, 64))
+}
+
+// constant approximates a constant, since none of them are rational.
+func (s rationalSystem) constant(name string) (interface{}, error) {
+	return s.approximate(constants[name])
+}
+
+func (s rationalSystem) owns(x interface{}) bool {
+	switch x.(type) {
+	case *big.Rat, float64:
+		return true
+	}
+	return false
+}
+
+// isApproximation reports whether x is a float64 approximation rather than an exact number.
+func (s rationalSystem) isApproximation(x interface{}) bool {
+	_, ok := x.(float64)
+	return ok
+}
+
+// approximate returns a result which isn't rational, or ErrNotRational if approximations aren't allowed.
+func (s rationalSystem) approximate(x float64) (interface{}, error) {
+	if s.inexact == RejectInexact {
+		return nil, ErrNotRational
+	}
+	return x, nil
+}
+
+func (s rationalSystem) unary(op string, x interface{}) (interface{}, error) {
+	if op != subtract {
+		return nil, unsupportedOperator(op)
+	}
+
+	if f, ok := x.(float64); ok {
+		return -f, nil
+	}
+	return new(big.Rat).Neg(x.(*big.Rat)), nil
+}
+
+func (s rationalSystem) operate(op string, left, right interface{}) (interface{}, error) {
+	l, lExact := left.(*big.Rat)
+	r, rExact := right.(*big.Rat)
+	if !lExact || !rExact {
+		// anything computed from an approximation is approximate
+		return operate(op, s.float(left), s.float(right))
+	}
+
+	switch op {
+	case add:
+		return s.check(new(big.Rat).Add(l, r))
+	case subtract:
+		return s.check(new(big.Rat).Sub(l, r))
+	case multiply:
+		return s.check(new(big.Rat).Mul(l, r))
+	case divide, floorDivide, modulus:
+		if r.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+
+		quotient := new(big.Rat).Quo(l, r)
+		switch op {
+		case divide:
+			return s.check(quotient)
+		case floorDivide:
+			return new(big.Rat).SetInt(floorRat(quotient)), nil
+		default:
+			// the remainder of the truncated quotient takes the sign of the dividend
+			truncated := new(big.Rat).SetInt(truncRat(quotient))
+			return s.check(truncated.Mul(truncated, r).Sub(l, truncated))
+		}
+	case power:
+		return s.power(l, r)
+	}
+
+	return nil, unsupportedOperator(op)
+}
+
+// power raises base to the power of exp. Integer powers and the square roots of perfect squares
+// are exact, while other powers are approximated.
+func (s rationalSystem) power(base, exp *big.Rat) (interface{}, error) {
+	if err := checkPower(base.Sign() == 0, exp.Sign() < 0); err != nil {
+		return nil, err
+	}
+
+	if !exp.IsInt() {
+		// a power with a denominator of 2 is a power of a square root
+		if exp.Denom().Cmp(big.NewInt(2)) == 0 && base.Sign() >= 0 {
+			if root, ok := sqrtRat(base); ok {
+				return s.power(root, new(big.Rat).SetInt(exp.Num()))
+			}
+		}
+
+		result, err := operate(power, s.float(base), s.float(exp))
+		if err != nil {
+			return nil, err
+		}
+		return s.approximate(result)
+	}
+
+	switch {
+	case exp.Sign() == 0:
+		return big.NewRat(1, 1), nil
+	case base.Sign() == 0, base.Cmp(big.NewRat(1, 1)) == 0:
+		return base, nil
+	case base.Cmp(big.NewRat(-1, 1)) == 0:
+		if exp.Num().Bit(0) == 0 {
+			return big.NewRat(1, 1), nil
+		}
+		return base, nil
+	}
+
+	n := new(big.Int).Abs(exp.Num())
+	if !exactPowerFits(base.Num().BitLen()+base.Denom().BitLen(), n) {
+		return nil, ErrOverflow
+	}
+
+	result := new(big.Rat).SetFrac(
+		new(big.Int).Exp(base.Num(), n, nil),
+		new(big.Int).Exp(base.Denom(), n, nil),
+	)
+	if exp.Sign() < 0 {
+		result.Inv(result)
+	}
+	return result, nil
+}
+
+// compare compares approximations by their float64 values.
+func (s rationalSystem) compare(op string, left, right interface{}) (bool, error) {
+	l, lok := left.(*big.Rat)
+	r, rok := right.(*big.Rat)
+	if lok && rok {
+		return compared(op, l.Cmp(r)), nil
+	}
+	return compared(op, compareFloats(s.float(left), s.float(right))), nil
+}
+
+func (s rationalSystem) call(name string, fn function, args []interface{}) (interface{}, error) {
+	rats := make([]*big.Rat, len(args))
+	for i, arg := range args {
+		x, ok := arg.(*big.Rat)
+		if !ok {
+			// anything computed from an approximation is approximate
+			return s.callFloat(fn, args)
+		}
+		rats[i] = x
+	}
+
+	x := rats[0]
+	switch name {
+	case "abs":
+		return new(big.Rat).Abs(x), nil
+	case "floor":
+		return new(big.Rat).SetInt(floorRat(x)), nil
+	case "ceil":
+		ceil := floorRat(new(big.Rat).Neg(x))
+		return new(big.Rat).SetInt(ceil.Neg(ceil)), nil
+	case "trunc":
+		return new(big.Rat).SetInt(truncRat(x)), nil
+	case "round":
+		// halves are rounded away from zero
+		half := big.NewRat(int64(x.Sign()), 2)
+		return new(big.Rat).SetInt(truncRat(half.Add(half, x))), nil
+	case "min", "max":
+		result := x
+		for _, arg := range rats[1:] {
+			cmp := arg.Cmp(result)
+			if name == "min" && cmp < 0 || name == "max" && cmp > 0 {
+				result = arg
+			}
+		}
+		return result, nil
+	case "sqrt":
+		if x.Sign() < 0 {
+			return nil, ErrDomain
+		}
+		if root, ok := sqrtRat(x); ok {
+			return root, nil
+		}
+	case "pow":
+		return s.operate(power, x, rats[1])
+	case factorialName, "nCr", "nPr":
+		integers := make([]*big.Int, len(rats))
+		for i, arg := range rats {
+			if !arg.IsInt() {
+				return nil, ErrDomain
+			}
+			integers[i] = arg.Num()
+		}
+		result, err := combinatorial(name, integers)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).SetInt(result), nil
+	}
+
+	result, err := s.callFloat(fn, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.approximate(result.(float64))
+}
+
+// callFloat applies a library function to the float64 approximations of its arguments.
+func (s rationalSystem) callFloat(fn function, args []interface{}) (interface{}, error) {
+	floats := make([]float64, len(args))
+	for i, arg := range args {
+		floats[i] = s.float(arg)
+	}
+
+	result, err := fn.call(floats)
+	if err == nil {
+		err = checkFinite(result, ErrDomain, floats...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// check returns an exact result, or ErrOverflow if it has grown too large to keep computing with.
+func (s rationalSystem) check(x *big.Rat) (interface{}, error) {
+	if x.Num().BitLen()+x.Denom().BitLen() > maxExactBits {
+		return nil, ErrOverflow
+	}
+	return x, nil
+}
+
+func (s rationalSystem) float(x interface{}) float64 {
+	if f, ok := x.(float64); ok {
+		return f
+	}
+	f, _ := x.(*big.Rat).Float64()
+	return f
+}
+
+func (s rationalSystem) integer(x interface{}) (*big.Int, bool) {
+	r, ok := x.(*big.Rat)
+	if !ok {
+		return floatInteger(x.(float64))
+	}
+	if !r.IsInt() {
+		return nil, false
+	}
+	return new(big.Int).Set(r.Num()), true
+}
+
+func (s rationalSystem) format(x interface{}) string {
+	r, ok := x.(*big.Rat)
+	if !ok {
+		return formatFloat(x.(float64))
+	}
+
+	switch s.style {
+	case MixedNumber:
+		return mixedNumber(r)
+	case DecimalExpansion:
+		return decimalExpansion(r)
+	}
+	return r.RatString()
+}
+
+// floorRat returns the largest integer less than or equal to x.
+func floorRat(x *big.Rat) *big.Int {
+	// Euclidean division rounds down for positive divisors, which denominators always are
+	return new(big.Int).Div(x.Num(), x.Denom())
+}
+
+// truncRat returns the integer part of x.
+func truncRat(x *big.Rat) *big.Int {
+	return new(big.Int).Quo(x.Num(), x.Denom())
+}
+
+// sqrtRat returns the square root of a non-negative number if it is rational,
+// which it is when both its numerator and denominator are perfect squares.
+func sqrtRat(x *big.Rat) (*big.Rat, bool) {
+	num, den := new(big.Int).Sqrt(x.Num()), new(big.Int).Sqrt(x.Denom())
+	if new(big.Int).Mul(num, num).Cmp(x.Num()) != 0 || new(big.Int).Mul(den, den).Cmp(x.Denom()) != 0 {
+		return nil, false
+	}
+	return new(big.Rat).SetFrac(num, den), true
+}
+
+// mixedNumber writes x as its integer part followed by the remaining proper fraction, such as "-3 1/2".
+func mixedNumber(x *big.Rat) string {
+	whole, rem := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	switch {
+	case rem.Sign() == 0:
+		return whole.String()
+	case whole.Sign() == 0:
+		return x.RatString()
+	}
+	return whole.String() + " " + rem.Abs(rem).String() + "/" + x.Denom().String()
+}
+
+// decimalExpansion writes x as a decimal with its repeating digits in parentheses, such as "0.1(6)".
+// Expansions which don't repeat within maxRepeatingDigits are cut short with "...".
+func decimalExpansion(x *big.Rat) string {
+	var b strings.Builder
+	if x.Sign() < 0 {
+		b.WriteString(subtract)
+	}
+
+	num := new(big.Int).Abs(x.Num())
+	whole, rem := new(big.Int).QuoRem(num, x.Denom(), new(big.Int))
+	b.WriteString(whole.String())
+	if rem.Sign() == 0 {
+		return b.String()
+	}
+
+	// the digits repeat from the first remainder which is seen twice
+	var digits []byte
+	seen := map[string]int{}
+	digit := new(big.Int)
+	for rem.Sign() != 0 && len(digits) < maxRepeatingDigits {
+		if start, ok := seen[rem.String()]; ok {
+			b.WriteString(decimal)
+			b.Write(digits[:start])
+			b.WriteString("(" + string(digits[start:]) + ")")
+			return b.String()
+		}
+
+		seen[rem.String()] = len(digits)
+		digit.QuoRem(rem.Mul(rem, bigTen), x.Denom(), rem)
+		digits = append(digits, byte('0'+digit.Int64()))
+	}
+
+	b.WriteString(decimal)
+	b.Write(digits)
+	if rem.Sign() != 0 {
+		b.WriteString("...")
+	}
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package solver
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestSolve_rational(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		format     RationalFormat
+		wantResult string
+	}{
+		{"sum of fractions", "1/3 + 1/6", Fraction, "1/2"},
+		{"decimal fractions", "0.1 + 0.2", Fraction, "3/10"},
+		{"scientific literal", "1.5e-3", Fraction, "3/2000"},
+		{"integer", "6/3", Fraction, "2"},
+		{"negative fraction", "-7/2", Fraction, "-7/2"},
+		{"no rounding", "(1/3) * 3 - 1", Fraction, "0"},
+		{"large integer", "2^100", Fraction, "1267650600228229401496703205376"},
+		{"negative power", "(2/3)^-2", Fraction, "9/4"},
+		{"perfect square root", "sqrt(9/16) + (1/4)^0.5", Fraction, "5/4"},
+		{"floor division", "-7 // 2", Fraction, "-4"},
+		{"modulus", "-7.5 % 2", Fraction, "-3/2"},
+		{"rounding", "round(-5/2) + ceil(-1/2) + floor(5/2)", Fraction, "-1"},
+		{"minimum", "min(1/3, 1/4, 1/2)", Fraction, "1/4"},
+		{"program", "x = 1/3; y = x * 3; y - 1", Fraction, "0"},
+		{"user-defined function", "f(x) = x / 3; f(1) + f(2)", Fraction, "1"},
+		{"mixed number", "7/2", MixedNumber, "3 1/2"},
+		{"negative mixed number", "-7/2", MixedNumber, "-3 1/2"},
+		{"proper fraction", "-1/2", MixedNumber, "-1/2"},
+		{"whole number", "8/2", MixedNumber, "4"},
+		{"terminating decimal", "7/4", DecimalExpansion, "1.75"},
+		{"repeating decimal", "1/3", DecimalExpansion, "0.(3)"},
+		{"delayed repetition", "-1/6", DecimalExpansion, "-0.1(6)"},
+		{"long repetition", "22/7", DecimalExpansion, "3.(142857)"},
+		{"whole decimal", "10/5", DecimalExpansion, "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, WithRational(tt.format, RejectInexact))
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_rationalErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+	}{
+		{"division by zero", "1 / (1/3 + 2/3 - 1)", ErrDivisionByZero},
+		{"negative power of zero", "0^-1", ErrDivisionByZero},
+		{"irrational square root", "sqrt(2)", ErrNotRational},
+		{"irrational power", "2^(1/3)", ErrNotRational},
+		{"irrational function", "sin(1)", ErrNotRational},
+		{"irrational constant", "2 * pi", ErrNotRational},
+		{"domain", "sqrt(-1/4)", ErrDomain},
+		{"overflow", "10^10^10", ErrOverflow},
+		{"undefined name", "x + 1", ErrUndefinedName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, WithRational(Fraction, RejectInexact))
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEval_rationalApproximations(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		wantResult   string
+		wantWarnings []string
+	}{
+		{"exact", "1/3", "1/3", nil},
+		{
+			"irrational function", "sqrt(2) * 2",
+			"2.8284271247461903",
+			[]string{`"sqrt(2)" is not rational and was approximated at column 1`},
+		},
+		{
+			"irrational constant", "1/2 + pi",
+			"3.641592653589793",
+			[]string{`"pi" is not rational and was approximated at column 7`},
+		},
+		{
+			"several approximations", "sin(0.5) + 2^0.5",
+			"1.8936391009772982",
+			[]string{
+				`"sin(0.5)" is not rational and was approximated at column 1`,
+				`"2^0.5" is not rational and was approximated at column 12`,
+			},
+		},
+		{
+			"inside function", "f(x) = sqrt(x); f(1/4) + f(2)",
+			"1.9142135623730951",
+			[]string{`"f(2)" is not rational and was approximated in statement 2 at column 26`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Eval(tt.expression, WithRational(Fraction, ApproximateInexact))
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.String() != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+			if !reflect.DeepEqual(result.Warnings(), tt.wantWarnings) {
+				t.Errorf("\nGot:\t%q\nWant:\t%q", result.Warnings(), tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestResult_Rat(t *testing.T) {
+	result, err := Eval("1/3 + 1/6", WithRational(Fraction, RejectInexact))
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	if got, ok := result.Rat(); !ok || got.Cmp(big.NewRat(1, 2)) != 0 {
+		t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", got, ok, big.NewRat(1, 2), true)
+	}
+	if result.Float() != 0.5 {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", result.Float(), 0.5)
+	}
+
+	// approximations and results of other evaluations have no exact value
+	for _, opts := range [][]Option{
+		{WithRational(Fraction, ApproximateInexact)},
+		nil,
+	} {
+		result, err := Eval("sqrt(2)", opts...)
+		if err != nil {
+			t.Fatalf("\nUnexpected error:\t%v", err)
+		}
+		if got, ok := result.Rat(); ok {
+			t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", got, ok, nil, false)
+		}
+	}
+}
+
+func TestEvalWithEnv_rational(t *testing.T) {
+	env := NewEnv(map[string]float64{"price": 0.1})
+	opt := WithRational(Fraction, RejectInexact)
+
+	// variables are converted by their shortest decimal form
+	result, err := EvalWithEnv("share = price / 3", env, opt)
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "1/30" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "1/30")
+	}
+
+	// assignments keep their exact value for later evaluations in the same mode
+	result, err = EvalWithEnv("share * 3", env, opt)
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "1/10" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "1/10")
+	}
+}
+
+func Test_decimalExpansion(t *testing.T) {
+	tests := []struct {
+		x    *big.Rat
+		want string
+	}{
+		{big.NewRat(0, 1), "0"},
+		{big.NewRat(-5, 4), "-1.25"},
+		{big.NewRat(1, 7), "0.(142857)"},
+		{big.NewRat(1, 12), "0.08(3)"},
+		{big.NewRat(1, 97), "0.(010309278350515463917525773195876288659793814432989690721649484536082474226804123711340206185567)"},
+		{big.NewRat(1, 109), "0.0091743119266055045871559633027522935779816513761467889908256880733944954128440366972477064220183486..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.x.String(), func(t *testing.T) {
+			if got := decimalExpansion(tt.x); got != tt.want {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", got, tt.want)
+			}
+		})
+	}
+}
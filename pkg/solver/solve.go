@@ -1,264 +1,228 @@
 package solver
 
 import (
+	"math"
+	"math/big"
 	"strconv"
-	"strings"
 )
 
-// Solve computes and returns the result of the given expression.
-func Solve(expr string) string {
-	// remove whitespaces in expression
-	expr = strings.Join(strings.Fields(expr), "")
-	resolved := resolveParentheses(expr)
-	result := evaluate(resolved)
-	return strconv.FormatFloat(result, 'f', -1, 64)
-
+// Result is the value of a successfully evaluated expression.
+type Result struct {
+	value float64
+	// exact is the value in the number system the expression was evaluated in, if not float64.
+	exact    interface{}
+	system   numberSystem
+	warnings []string
+	// percent reports whether the result is a number of percent, as that of "20 as % of 80",
+	// which is written followed by "%".
+	percent bool
+	// ref is the result if it isn't a number, such as a boolean or a string, in which case value is its approximation.
+	ref Value
 }
 
-// resolveParentheses parses an expression with parentheses and returns its simplified form.
-func resolveParentheses(expr string) string {
-	simplified := simplifyParentheses(expr)
-	evaluated := evaluateParentheses(simplified)
-	return evaluated
+// Float returns the result as a floating-point number.
+// Results of evaluations with WithPrecision are rounded to the nearest float64,
+// those of evaluations with WithComplex are NaN if they have an imaginary part,
+// those of evaluations with WithUnits are in the units returned by Unit,
+// booleans are 1 for true and 0 for false, and strings and lists are NaN.
+func (r Result) Float() float64 {
+	return r.value
 }
 
-// simplifyParentheses groups the parentheses in the expression
-// and returns a list containing the groups as individual expressions
-// alongside the expressions not covered by parentheses.
-func simplifyParentheses(expr string) []string {
-	var nested bool
-	var result []string
-	var buffer string
-
-	for pos, char := range expr {
-		value := string(char)
-
-		switch value {
-		case openParenthesis:
-			switch string(expr[pos-1]) {
-			// look behind to append a multiplication if no operator was found before the parenthesis
-			case add, subtract, multiply, divide:
-			default:
-				result = append(result, buffer)
-				result = append(result, multiply)
-			}
-			nested = true
-			buffer = ""
-
-		case closeParenthesis:
-			// flush all values contained within the parentheses
-			nested = false
-			result = append(result, buffer)
-			buffer = ""
-
-		case add, subtract, multiply, divide:
-			if !nested {
-				if buffer != "" {
-					// flush any existing preceding operand to the result
-					result = append(result, buffer)
-					buffer = ""
-				}
-
-				// add the operator to the result
-				result = append(result, value)
-			} else {
-				// accumulate operators as part of sub-expressions
-				buffer += value
-			}
-
-		default:
-			// accumulate digits, nested or not
-			buffer += value
+// Bool returns the result of a comparison or logical expression, such as "age >= 18 && income > 30000",
+// and false if the result is a number rather than a boolean.
+func (r Result) Bool() (bool, bool) {
+	b, ok := r.ref.(Bool)
+	return bool(b), ok
+}
 
-			if pos == len(expr)-1 {
-				// flush the final contents of the buffer
-				result = append(result, buffer)
-			}
-		}
+// Type returns the type of the result, so that callers can tell numbers from booleans, strings and lists.
+func (r Result) Type() Type {
+	if r.ref == nil {
+		return NumberType
 	}
-
-	return result
+	return r.ref.Type()
 }
 
-// evaluateParentheses computes the value of each sub-expression in the give list of expressions.
-// Operators are not evaluated.
-// The result is returned as a string with the simplified expression.
-func evaluateParentheses(exprs []string) string {
-	var evaluated []string
-
-	for _, expr := range exprs {
-		switch expr {
-		case add, subtract, multiply, divide:
-			// do not evaluate single operators
-			evaluated = append(evaluated, expr)
-
-		default:
-			evaluatedExpr := evaluate(expr)
-			evaluated = append(evaluated, strconv.FormatFloat(evaluatedExpr, 'f', -1, 64))
-		}
+// Value returns the result as a Value. Results of evaluations in other number systems,
+// such as with WithRational, are approximated by their float64 values.
+func (r Result) Value() Value {
+	if r.ref == nil {
+		return Number(r.value)
 	}
-
-	result := strings.Join(evaluated, "")
-	return result
+	return publicValue(r.ref)
 }
 
-// evaluate computes a simplified expression list (with only addition and subtraction operators)
-// into a final float result.
-func evaluate(expr string) float64 {
-	// return single digits early
-	if len(expr) == 1 {
-		result, _ := strconv.ParseFloat(expr, 64)
-		return result
+// Rows returns the numbers of a result which is a matrix, a list of lists of numbers of the same length,
+// row by row and written as String writes them, and false if the result isn't a matrix.
+func (r Result) Rows() ([][]string, bool) {
+	rows, ok := matrixOf(r.ref)
+	if !ok {
+		return nil, false
 	}
 
-	simplified := simplify(expr)
-	simplified = splitUnary(simplified)
-
-	var result float64
-
-	// the flags determine the next operation
-	var addFlag = true
-	var subtractFlag bool
-
-	for _, value := range simplified {
-		switch value {
-		case add:
-			addFlag = true
-			subtractFlag = false
-
-		case subtract:
-			subtractFlag = true
-			addFlag = false
-
-		default:
-			valueDigit, _ := strconv.ParseFloat(value, 64)
-
-			// add or subtract based on active flag
-			if addFlag {
-				result = result + valueDigit
-			} else if subtractFlag {
-				result = result - valueDigit
-			}
-
-			addFlag = false
-			subtractFlag = false
+	text := make([][]string, len(rows))
+	for i, row := range rows {
+		text[i] = make([]string, len(row))
+		for j, x := range row {
+			text[i][j] = x.String()
 		}
 	}
-
-	return result
+	return text, true
 }
 
-// simplify reduces an expression to have only addition and subtraction operators.
-// An error is returned if a non-digit or non-operator character is found.
-func simplify(expr string) []string {
-	legalExpr := normalize(expr)
-	var simplified []string
-	var skipFlag bool
-
-	for pos, value := range legalExpr {
-		switch value {
-		// simplify multiplications and divisions by
-		// evaluating the preceding and succeeding operands
-		case multiply, divide:
+// Complex returns the result as a complex number, whose imaginary part is 0
+// unless the result is of an evaluation with WithComplex.
+func (r Result) Complex() complex128 {
+	if z, ok := r.exact.(complex128); ok {
+		return z
+	}
+	return complex(r.value, 0)
+}
 
-			// all ParseFloats are guaranteed to work as illegal characters have
-			// already been guarded against
-			prev, _ := strconv.ParseFloat(simplified[len(simplified)-1], 64)
-			next, _ := strconv.ParseFloat(legalExpr[pos+1], 64)
+// Unit returns the units a result of an evaluation with WithUnits is written in, such as m/s,
+// and "" if it has none or was evaluated some other way.
+func (r Result) Unit() string {
+	if q, ok := r.exact.(quantity); ok {
+		return q.unitText()
+	}
+	return ""
+}
 
-			// replace previously added value in legalExp with the multiplication/division evaluation
-			if value == multiply {
-				simplified[len(simplified)-1] = strconv.FormatFloat(prev*next, 'f', -1, 64)
-			} else {
-				simplified[len(simplified)-1] = strconv.FormatFloat(prev/next, 'f', -1, 64)
-			}
-			skipFlag = true
+// Currency returns the code of the currency of a result of an evaluation with WithCurrency, such as USD,
+// and "" if it is a plain number or was evaluated some other way.
+func (r Result) Currency() string {
+	if m, ok := r.exact.(money); ok {
+		return m.currency
+	}
+	return ""
+}
 
-		default:
-			// skip current operand if previous operation was multiplication or division
-			// as it has already been evaluated
-			if !skipFlag {
-				simplified = append(simplified, value)
-			}
-			skipFlag = false
-		}
+// String returns the result in its shortest decimal form.
+// Results of evaluations with WithPrecision are rounded to the precision given,
+// and those of evaluations with WithRational are written in the format given.
+// Infinite results are written the same way as the inf constant,
+// results of "as % of" are followed by "%", as in 25%, and other values are written as in expressions,
+// such as true or "km".
+func (r Result) String() string {
+	if r.ref != nil {
+		return r.ref.String()
 	}
 
-	return simplified
+	text := formatFloat(r.value)
+	if r.system != nil {
+		text = r.system.format(r.exact)
+	}
+	if r.percent {
+		text += percent
+	}
+	return text
 }
 
-// normalize returns a list of each element in the expression.
-func normalize(expr string) []string {
-	//Solve consecutive addition and subtraction operators
-	replacer := strings.NewReplacer("+-", "-", "--", "+")
-	expr = replacer.Replace(expr)
-	var legalExp []string
-
-	// the operand is accumulated and added to legalExp when an operator is found
-	var operand string
+// Rat returns the exact value of a result of an evaluation with WithRational,
+// and false if the result was approximated or evaluated some other way.
+func (r Result) Rat() (*big.Rat, bool) {
+	x, ok := r.exact.(*big.Rat)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).Set(x), true
+}
 
-	for pos, char := range expr {
-		value := string(char)
+// Int returns the exact value of a result of an evaluation with WithInteger,
+// and false if the result was evaluated some other way.
+func (r Result) Int() (*big.Int, bool) {
+	x, ok := r.exact.(*big.Int)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).Set(x), true
+}
 
-		if pos == 0 {
-			switch value {
-			// allow only subtraction as a starting operator
-			case subtract:
-				legalExp = append(legalExp, value)
+// Warnings describes the approximations made while computing the result,
+// such as of functions whose results aren't rational in an evaluation with WithRational.
+func (r Result) Warnings() []string {
+	return r.warnings
+}
 
-			default:
-				operand += value
-			}
-		} else if pos == len(expr)-1 {
-			// accumulate final operand and append to legalExp
-			operand += value
-			legalExp = append(legalExp, operand)
-		} else {
-			switch value {
-			case add, multiply, divide:
-				// append and reset operand
-				legalExp = append(legalExp, operand)
-				operand = ""
-				// append operator
-				legalExp = append(legalExp, value)
+// basePrefixes are the prefixes of results written in bases which have literals.
+var basePrefixes = map[int]string{16: "0x", 8: "0o", 2: "0b"}
+
+// Text returns the result written in a base from 2 to 36, or fails with ErrBase for other bases.
+// Results in bases 16, 8 and 2 are prefixed with 0x, 0o and 0b, like the literals of those bases.
+// Results which aren't integers can only be written in base 10, and fail with ErrNotInteger otherwise.
+// Values other than numbers are written as by String in every base.
+func (r Result) Text(base int) (string, error) {
+	switch {
+	case base < 2 || base > 36:
+		return "", ErrBase
+	case base == 10, r.ref != nil:
+		return r.String(), nil
+	}
 
-			case subtract:
-				// group subtraction with the next operand as a unary operator
-				if operand != "" {
-					// a separate operand already precedes the subtraction sign
-					legalExp = append(legalExp, operand)
-				}
+	var n *big.Int
+	var ok bool
+	if r.system != nil {
+		n, ok = r.system.integer(r.exact)
+	} else {
+		n, ok = floatInteger(r.value)
+	}
+	if !ok {
+		return "", ErrNotInteger
+	}
 
-				operand = ""
-				operand += value
+	text := basePrefixes[base] + new(big.Int).Abs(n).Text(base)
+	if n.Sign() < 0 {
+		text = subtract + text
+	}
+	if r.percent {
+		text += percent
+	}
+	return text, nil
+}
 
-			default:
-				// accumulate operand
-				operand += value
-			}
-		}
+// formatFloat returns a float64 in its shortest decimal form.
+// Infinities are written the same way as the inf constant.
+func formatFloat(x float64) string {
+	switch {
+	case math.IsInf(x, 1):
+		return "inf"
+	case math.IsInf(x, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(x, 'f', -1, 64)
 	}
+}
 
-	return legalExp
+// Solve computes and returns the result of the given expression.
+// It is a shorthand for formatting the result of Eval.
+func Solve(expr string, opts ...Option) (string, error) {
+	result, err := Eval(expr, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
 }
 
-// splitUnary separates all subtraction signs in the expression from their operands.
-func splitUnary(expr []string) []string {
-	var result []string
+// Eval validates and computes the value of the given expression.
+// A *SyntaxError is returned if the expression is malformed,
+// and an *EvalError if its value cannot be computed.
+func Eval(expr string, opts ...Option) (Result, error) {
+	return EvalWithEnv(expr, nil, opts...)
+}
 
-	for pos, value := range expr {
-		if pos == 0 {
-			// allow only the first operand with a negation retain its sign
-			result = append(result, value)
-		} else {
-			if string(value[0]) == subtract {
-				result = append(result, subtract)
-				result = append(result, value[1:])
-			} else {
-				result = append(result, value)
-			}
-		}
+// EvalWithEnv validates and computes the value of the given expression,
+// resolving the names it refers to from env before the constants.
+// If any name can't be resolved, the returned *EvalError wraps an *UndefinedError listing them all.
+//
+// The expression may be a program of statements separated by semicolons, such as
+// "x = 3; f(a, b) = a^2 + b; f(x, 1) / 2". Assignments and function definitions are stored in env,
+// which may be nil if they don't need to be kept, and the result is the value of the last statement.
+// A function definition has a value of 0.
+func EvalWithEnv(expr string, env *Env, opts ...Option) (Result, error) {
+	program, err := Compile(expr, opts...)
+	if err != nil {
+		return Result{}, err
 	}
-
-	return result
+	return program.Eval(env)
 }
@@ -1,7 +1,10 @@
 package solver
 
 import (
+	"errors"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -17,11 +20,35 @@ func TestSolve(t *testing.T) {
 		{"4", "50 * 4 + 9 * 3 - 6 * 40000000000000", "-239999999999773"},
 		{"5", "2(3.54 * 2.00 -1000 /200) / (20 + 30 * 2)", "0.052000000000000005"},
 		{"6", " 2(3.54 * 2.00 -1000 /200) (20 + 30 * 2)", "332.8"},
+		{"7", "2*((3+4)*(5-1))", "56"},
+		{"8", "2(3(4+1))", "30"},
+		{"9", "((1+2)(3+4) - (5))(2)", "32"},
+		{"10", "7 % 3 + 2^3 * 2", "17"},
+		{"11", "2**3**2 // 10", "51"},
+		{"12", "sqrt(2^2 + 3 * 4) * 3", "12"},
+		{"13", "max(4, 7) - min(4, 7, -1)", "8"},
+		{"14", "2pi - tau", "0"},
+		{"15", "3(e+1) - 3e - 3", "0"},
+		{"16", "-inf", "-inf"},
+		{"17", "1 / inf", "0"},
+		{"18", "x = 3; y = x^2 + 1; y / 2", "5"},
+		{"19", "x = 3; x = x * 2; x = x + 1", "7"},
+		{"20", "a = 2; b = a * (a + 1); 2b;", "12"},
+		{"21", "0xFF + 0o17 + 0b1010", "280"},
+		{"22", "1.5e-3 * 2E3", "3"},
+		{"23", "1_000_000 / 1e6", "1"},
+		{"24", "2e - 2e+0", "3.43656365691809"},
+		{"25", "7 % -2", "1"},
+		{"26", "10 % -3 + 1", "2"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Solve(tt.expression)
+			result, err := Solve(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
 			if result != tt.wantResult {
 				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
 			}
@@ -29,98 +56,430 @@ func TestSolve(t *testing.T) {
 	}
 }
 
-func Test_resolveParentheses(t *testing.T) {
+func TestProgram_Eval(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		result     float64
+	}{
+		{"single positive operand", "4", 4},
+		{"single negative operand", "-4", -4},
+		{"addition only", "8+6", 14},
+		{"subtraction only", "8-6", 2},
+		{"subtraction prefix", "-2+5.8", 3.8},
+		{"subtraction prefix", "-2-5.8", -7.8},
+		{"multiplication before addition", "3+5*2", 13},
+		{"division before subtraction", "3-6/2", 0},
+		{"left-associative subtraction", "8-4-2", 2},
+		{"left-associative division", "8/4/2", 1},
+		{"implicit multiplication", "2(3+4)", 14},
+		{"adjacent groups", "(1+1)(2+2)", 8},
+		{"negated group", "-(2+3)*2", -10},
+		{"double negation", "4--2", 6},
+		{"modulus", "7%3", 1},
+		{"modulus of negative dividend", "-7%3", -1},
+		{"modulus precedence", "1+7%3*2", 3},
+		{"floor division", "7//2", 3},
+		{"floor division of negative dividend", "-7//2", -4},
+		{"exponentiation", "2^10", 1024},
+		{"exponentiation alias", "2**10", 1024},
+		{"right-associative exponentiation", "2^3^2", 512},
+		{"exponentiation before negation", "-2^2", -4},
+		{"negative exponent", "2^-1", 0.5},
+		{"exponentiation before multiplication", "3*2^2", 12},
+		{"exponent of group", "(1+1)^3", 8},
+		{"square root", "sqrt(16)", 4},
+		{"cube root", "cbrt(-27)", -3},
+		{"natural logarithm", "ln(1)", 0},
+		{"common logarithm", "log(1000)", 3},
+		{"logarithm with base", "log(8, 2)", 3},
+		{"binary logarithm", "log2(1024)", 10},
+		{"trigonometry", "sin(0) + cos(0) + tan(0)", 1},
+		{"inverse trigonometry", "atan2(0, 1) + asin(0)", 0},
+		{"absolute value", "abs(-3.5)", 3.5},
+		{"rounding", "floor(2.5) + ceil(2.5) + round(2.5) + trunc(-2.5)", 6},
+		{"variadic minimum", "min(3, -1, 2)", -1},
+		{"variadic maximum", "max(3)", 3},
+		{"factorial", "factorial(5)", 120},
+		{"call in exponent", "2^abs(-3)", 8},
+		{"implicit multiplication of call argument", "abs(-2)(3)", 6},
+		{"pi", "pi", math.Pi},
+		{"tau", "tau", 2 * math.Pi},
+		{"euler's number", "e", math.E},
+		{"golden ratio", "phi", math.Phi},
+		{"infinity", "inf + 1", math.Inf(1)},
+		{"implicit multiplication of constant", "2pi", 2 * math.Pi},
+		{"implicit multiplication of constant after group", "(1+1)e", 2 * math.E},
+		{"implicit multiplication of call", "3sqrt(4)", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			result, err := program.Eval(nil)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.Float() != tt.result {
+				t.Errorf("\nGot:\t%f\nWant:\t%f", result.Float(), tt.result)
+			}
+		})
+	}
+}
+
+func TestResult_Text(t *testing.T) {
 	tests := []struct {
 		name       string
 		expression string
+		opts       []Option
+		base       int
 		wantResult string
+		wantErr    error
 	}{
-		{"1", "2+5-2(6+4)+3", "2+5-2*10+3"},
-		{"2", "-2+5-2(6+4)+3", "-2+5-2*10+3"},
-		{"3", "2+5-2(6*4)+3", "2+5-2*24+3"},
+		{"hexadecimal", "255", nil, 16, "0xff", nil},
+		{"octal", "15", nil, 8, "0o17", nil},
+		{"binary", "-10", nil, 2, "-0b1010", nil},
+		{"other base", "35", nil, 36, "z", nil},
+		{"decimal", "0.5", nil, 10, "0.5", nil},
+		{"large float", "2^70", nil, 16, "0x400000000000000000", nil},
+		{"integer mode", "2^100 - 1", []Option{WithInteger()}, 16, "0xfffffffffffffffffffffffff", nil},
+		{"decimal mode", "0.1 * 30", []Option{WithPrecision(10)}, 2, "0b11", nil},
+		{"rational mode", "4/2", []Option{WithRational(Fraction, RejectInexact)}, 16, "0x2", nil},
+		{"fraction", "0.5", nil, 16, "", ErrNotInteger},
+		{"infinity", "inf", nil, 16, "", ErrNotInteger},
+		{"exact fraction", "1/3", []Option{WithRational(Fraction, RejectInexact)}, 2, "", ErrNotInteger},
+		{"base too small", "2", nil, 1, "", ErrBase},
+		{"base too large", "2", nil, 37, "", ErrBase},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := resolveParentheses(tt.expression)
-			if result != tt.wantResult {
-				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			result, err := Eval(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			text, err := result.Text(tt.base)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+			if text != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", text, tt.wantResult)
 			}
 		})
 	}
 }
 
-func Test_evaluate(t *testing.T) {
+func TestEval_errors(t *testing.T) {
 	tests := []struct {
 		name       string
 		expression string
-		result     float64
+		wantErr    error
 	}{
-		{"single positive operand", "4", 4},
-		{"single negative operand", "-4", -4},
-		{"addition only", "8+6", 14},
-		{"subtraction only", "8-6", 2},
-		{"addition prefix", "+2-5.2", -3.2},
-		{"subtraction prefix", "-2+5.8", 3.8},
-		{"subtraction prefix", "-2-5.8", -7.8},
+		{"malformed expression", "2 +* 3", ErrIllegalConsecutiveOperator},
+		{"unbalanced parentheses", "(2 + 3", ErrMalformedExp},
+		{"division by zero", "1 / 0", ErrDivisionByZero},
+		{"division by zero expression", "4 / (2 - 2)", ErrDivisionByZero},
+		{"floor division by zero", "4 // 0", ErrDivisionByZero},
+		{"modulus by zero", "4 % 0", ErrDivisionByZero},
+		{"negative power of zero", "0 ^ -1", ErrDivisionByZero},
+		{"overflow", "10 ^ 400", ErrOverflow},
+		{"negative overflow", "-10 ^ 400 * 2", ErrOverflow},
+		{"overflowing literal", "1" + strings.Repeat("0", 400), ErrOverflow},
+		{"not a number", "(-8) ^ 0.5", ErrNotANumber},
+		{"unknown function", "2 * unknown(3)", ErrUnknownFunction},
+		{"too few arguments", "atan2(1)", ErrArgumentCount},
+		{"too many arguments", "sqrt(1, 2)", ErrArgumentCount},
+		{"missing arguments", "max()", ErrArgumentCount},
+		{"square root of negative", "sqrt(-1)", ErrDomain},
+		{"logarithm of zero", "ln(0)", ErrDomain},
+		{"logarithm in base 1", "log(8, 1)", ErrDomain},
+		{"arcsine outside unit interval", "asin(2)", ErrDomain},
+		{"function overflow", "exp(1000)", ErrOverflow},
+		{"literal overflow", "1e400", ErrOverflow},
+		{"hexadecimal literal overflow", "0x1" + strings.Repeat("0", 300), ErrOverflow},
+		{"factorial overflow", "factorial(171)", ErrOverflow},
+		{"factorial of fraction", "factorial(2.5)", ErrDomain},
+		{"integer operator", "6 & 3", ErrIntegerOperator},
+		{"error in argument", "sqrt(1/0)", ErrDivisionByZero},
+		{"undefined name", "2 * x", ErrUndefinedName},
+		{"function used as a constant", "sqrt + 1", ErrUndefinedName},
+		{"constant used as a function", "pi(2)", ErrUnknownFunction},
+		{"infinite difference", "inf - inf", ErrNotANumber},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := evaluate(tt.expression)
+			_, err := Eval(tt.expression)
 
-			if result != tt.result {
-				t.Errorf("\nGot:\t%f\nWant:\t%f", result, tt.result)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_simplify(t *testing.T) {
+func TestEval_evalError(t *testing.T) {
+	_, err := Eval("2 + 6 / (3 - 3)")
+
+	var evalErr *EvalError
+	if !errors.As(err, &evalErr) {
+		t.Fatalf("\nGot:\t%#v\nWant:\t*EvalError", err)
+	}
+
+	if evalErr.Offset != 4 || evalErr.Length != 11 || evalErr.Column != 5 {
+		t.Errorf("\nGot:\toffset %d, length %d, column %d\nWant:\toffset 4, length 11, column 5",
+			evalErr.Offset, evalErr.Length, evalErr.Column)
+	}
+}
+
+// TestEval_noPanic ensures that arbitrary input is reported as an error rather than crashing.
+func TestEval_noPanic(t *testing.T) {
+	inputs := []string{
+		"", " ", "(", ")", ")(", "()", "(((", "-", "--", "---", ".", "..", "2.", ".2",
+		"(2", "2)", "(-)", "2(", "(2)(", "*", "2*", "*2", "2**", "//", "%", "^", "2^^2",
+		"-(", "-)", "(-2", "2 3", "((2)", "f", "f(", "f)", "f(,", "f(1,", "f(1,)", ",", "(,)", "sqrt(-1", "pi e", "2pi(", "inf/inf", "inf*0", ";", "=", "x=", "=1", "x=;", ";;", "x=1;;", "1=1", "1/0", "0/0", "0^0", "\x00", "é", "2é",
+		strings.Repeat("(", 1000) + "1" + strings.Repeat(")", 1000),
+		strings.Repeat("-(", 500) + "1" + strings.Repeat(")", 500),
+		strings.Repeat("2^", 1000) + "2",
+	}
+
+	for _, input := range inputs {
+		_, _ = Eval(input)
+		_, _ = Parse(input)
+		_, _ = Validate(input)
+	}
+}
+
+func TestEvalWithEnv(t *testing.T) {
+	env := NewEnv(map[string]float64{"rate": 12.5, "hours": 8, "fee": 20, "pi": 3})
+
 	tests := []struct {
 		name       string
 		expression string
-		wantResult []string
+		wantResult string
 	}{
-		{"pure addition", "3+5+8", []string{"3", "+", "5", "+", "8"}},
-		{"pure subtraction", "3-5-8", []string{"3", "-5", "-8"}},
-		{"pure multiplication", "3*5.5*8", []string{"132"}},
-		{"pure division", "3/5/8", []string{"0.075"}},
-		{"mixed", "3+5-2*7/3+4", []string{"3", "+", "5", "-4.666666666666667", "+", "4"}},
-		{"subtraction prefix", "-2*3", []string{"-", "6"}},
-		{"joined operands", "2*4", []string{"8"}},
+		{"variables", "rate * hours + fee", "120"},
+		{"implicit multiplication of variable", "2rate", "25"},
+		{"variable as function argument", "max(rate, hours)", "12.5"},
+		{"variable shadowing constant", "2pi", "6"},
+		{"constant", "round(e)", "3"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := simplify(tt.expression)
-			if !reflect.DeepEqual(result, tt.wantResult) {
-				t.Errorf("\nGot:\t%#v\nWant:\t%#v", result, tt.wantResult)
+			result, err := EvalWithEnv(tt.expression, env)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.String() != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
 			}
 		})
 	}
 }
 
-func Test_normalize(t *testing.T) {
+func TestEvalWithEnv_undefined(t *testing.T) {
+	env := NewEnv(map[string]float64{"rate": 12.5})
+
+	_, err := EvalWithEnv("rate * hours + fee - hours", env)
+
+	if !errors.Is(err, ErrUndefinedName) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrUndefinedName)
+	}
+
+	var undefinedErr *UndefinedError
+	if !errors.As(err, &undefinedErr) {
+		t.Fatalf("\nGot:\t%#v\nWant:\t*UndefinedError", err)
+	}
+
+	if !reflect.DeepEqual(undefinedErr.Names, []string{"hours", "fee"}) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", undefinedErr.Names, []string{"hours", "fee"})
+	}
+
+	var evalErr *EvalError
+	if !errors.As(err, &evalErr) || evalErr.Offset != 7 {
+		t.Errorf("\nGot:\t%#v\nWant:\t*EvalError at offset 7", err)
+	}
+}
+
+func TestEvalWithEnv_program(t *testing.T) {
+	env := NewEnv(map[string]float64{"x": 10})
+
+	result, err := EvalWithEnv("y = x / 2; x = y + 1; x * y", env)
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	if result.String() != "30" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "30")
+	}
+
+	// assignments are kept in the environment
+	for name, want := range map[string]float64{"x": 6, "y": 5} {
+		if value, _ := env.Get(name); value != want {
+			t.Errorf("\nGot:\t%s = %v\nWant:\t%s = %v", name, value, name, want)
+		}
+	}
+}
+
+func TestEvalWithEnv_functions(t *testing.T) {
+	env := &Env{}
+
 	tests := []struct {
 		name       string
 		expression string
-		wantResult []string
+		wantResult string
+	}{
+		{"definition", "f(x, y) = x^2 + y", "0"},
+		{"call of earlier definition", "f(3, 4)", "13"},
+		{"implicit multiplication of call", "2f(1, 1)", "4"},
+		{"calls between functions", "g(x) = f(x, 1) / 2; g(3)", "5"},
+		{"parameter shadowing variable", "x = 100; h(x) = x + 1; h(1)", "2"},
+		{"function shadowing library function", "sqrt(x) = x; sqrt(9)", "9"},
+		{"redefinition", "f(x, y) = x - y; f(3, 4)", "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EvalWithEnv(tt.expression, env)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.String() != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEvalWithEnv_maxCallDepth(t *testing.T) {
+	env := &Env{}
+
+	if _, err := EvalWithEnv("a(x) = x + 1; b(x) = a(x) + 1; c(x) = b(x) + 1", env); err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	if _, err := EvalWithEnv("c(1)", env, WithMaxCallDepth(3)); err != nil {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, nil)
+	}
+
+	_, err := EvalWithEnv("1 + c(1)", env, WithMaxCallDepth(2))
+	if !errors.Is(err, ErrCallDepthExceeded) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrCallDepthExceeded)
+	}
+
+	var evalErr *EvalError
+	if !errors.As(err, &evalErr) || evalErr.Offset != 4 || evalErr.Length != 4 {
+		t.Errorf("\nGot:\t%#v\nWant:\t*EvalError at offset 4 with length 4", err)
+	}
+
+	// recursion without a base case stops at the default limit
+	if _, err := Eval("f(n) = n * f(n - 1); f(3)"); !errors.Is(err, ErrCallDepthExceeded) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrCallDepthExceeded)
+	}
+}
+
+func TestEval_programErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		expression    string
+		wantErr       error
+		wantOffset    int
+		wantStatement int
 	}{
-		{"addition", "4+3+2", []string{"4", "+", "3", "+", "2"}},
-		{"addition and subtraction", "4+3-2", []string{"4", "+", "3", "-2"}},
-		{"addition negation", "4+3+-2", []string{"4", "+", "3", "-2"}},
-		{"subtraction negation", "4+3--2", []string{"4", "+", "3", "+", "2"}},
-		{"combined multiplication and subtraction", "4+3*-2", []string{"4", "+", "3", "*", "-2"}},
-		{"combined division and subtraction", "4+3/-2", []string{"4", "+", "3", "/", "-2"}},
+		{"error in later statement", "x = 2; y = x / (x - 2); y", ErrDivisionByZero, 11, 2},
+		{"name used before assignment", "y = x + 1; x = 2", ErrUndefinedName, 4, 1},
+		{"self-referencing assignment", "x = x + 1", ErrUndefinedName, 4, 0},
+		{"undefined name in later statement", "x = 2; x + z", ErrUndefinedName, 11, 2},
+		{"undefined name in function body", "f(x) = x + y; y = 1", ErrUndefinedName, 11, 1},
+		{"parameter used outside function body", "f(x) = x; x", ErrUndefinedName, 10, 2},
+		{"error in function body", "f(x) = 1 / x; 2 + f(0)", ErrDivisionByZero, 18, 2},
+		{"wrong number of arguments", "f(x) = x; f(1, 2)", ErrArgumentCount, 10, 2},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := normalize(tt.expression)
-			if !reflect.DeepEqual(result, tt.wantResult) {
-				t.Errorf("\nGot:\t%#v\nWant:\t%#v", result, tt.wantResult)
+			_, err := Eval(tt.expression)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("\nGot:\t%#v\nWant:\t*EvalError", err)
+			}
+
+			if evalErr.Offset != tt.wantOffset || evalErr.Statement != tt.wantStatement {
+				t.Errorf("\nGot:\toffset %d, statement %d\nWant:\toffset %d, statement %d",
+					evalErr.Offset, evalErr.Statement, tt.wantOffset, tt.wantStatement)
 			}
 		})
 	}
 }
+
+// benchmarkExpr is a formula evaluated once per row of data in the benchmarks.
+const benchmarkExpr = "rate * hours + fee - max(0, hours - 40) * rate / 2"
+
+func BenchmarkSolve(b *testing.B) {
+	expr := strings.NewReplacer("rate", "12.5", "hours", "45", "fee", "20").Replace(benchmarkExpr)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Solve(expr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEvalWithEnv(b *testing.B) {
+	env := NewEnv(map[string]float64{"rate": 12.5, "hours": 45, "fee": 20})
+
+	for i := 0; i < b.N; i++ {
+		if _, err := EvalWithEnv(benchmarkExpr, env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProgram_Eval(b *testing.B) {
+	env := NewEnv(map[string]float64{"rate": 12.5, "hours": 45, "fee": 20})
+
+	program, err := Compile(benchmarkExpr)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := program.Eval(env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkFunctions is a program calling user-defined functions, evaluated in the benchmarks.
+const benchmarkFunctions = "pay(r, h) = r * h + max(0, h - 40) * r / 2; net(r, h) = pay(r, h) - fee; net(rate, hours)"
+
+func BenchmarkProgram_Eval_functions(b *testing.B) {
+	env := NewEnv(map[string]float64{"rate": 12.5, "hours": 45, "fee": 20})
+
+	program, err := Compile(benchmarkFunctions)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := program.Eval(env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
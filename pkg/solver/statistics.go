@@ -0,0 +1,359 @@
+package solver
+
+import (
+	"math"
+	"sort"
+)
+
+// maxIterations bounds the terms of the series and continued fractions of the incomplete gamma and beta functions,
+// which converge in far fewer unless their arguments are huge.
+const maxIterations = 10000
+
+// epsilon is the relative size of the terms at which the series and continued fractions stop.
+const epsilon = 1e-16
+
+// tiny replaces zeros in the continued fractions, which would otherwise divide by them.
+const tiny = 1e-300
+
+// combinations returns the number of ways nCr of choosing r of n items, which is 0 if r is greater than n.
+func combinations(args []float64) (float64, error) {
+	n, r := args[0], args[1]
+	if !naturalNumber(n) || !naturalNumber(r) {
+		return 0, ErrDomain
+	}
+	if r > n {
+		return 0, nil
+	}
+
+	// choosing r items is choosing the n - r which are left
+	r = math.Min(r, n-r)
+	result := 1.0
+	for i := 1.0; i <= r && !math.IsInf(result, 1); i++ {
+		result = result * (n - r + i) / i
+	}
+	return math.Round(result), nil
+}
+
+// permutations returns the number of ways nPr of arranging r of n items in order, which is 0 if r is greater than n.
+func permutations(args []float64) (float64, error) {
+	n, r := args[0], args[1]
+	if !naturalNumber(n) || !naturalNumber(r) {
+		return 0, ErrDomain
+	}
+	switch {
+	case r > n:
+		return 0, nil
+	case r == 0:
+		return 1, nil
+	}
+
+	// the factors are counted rather than stepped through, as n - r + 1 may round to n when n is large
+	result := 1.0
+	for i := 0.0; i < r && !math.IsInf(result, 1); i++ {
+		result *= n - i
+	}
+	return result, nil
+}
+
+// gamma returns the gamma function of x, which extends the factorial so that gamma(n) is (n - 1)!.
+// It isn't defined for 0 and negative integers.
+func gamma(args []float64) (float64, error) {
+	x := args[0]
+	if x <= 0 && x == math.Trunc(x) {
+		return 0, ErrDomain
+	}
+	return math.Gamma(x), nil
+}
+
+// percentile returns the pth percentile of the arguments before the last one, which is p,
+// interpolating linearly between the two nearest arguments as spreadsheets do, so that the 50th percentile is the median.
+func percentile(args []float64) (float64, error) {
+	if len(args) < 2 {
+		return 0, ErrDomain
+	}
+
+	p := args[len(args)-1]
+	if p < 0 || p > 100 {
+		return 0, ErrDomain
+	}
+
+	sorted := append([]float64(nil), args[:len(args)-1]...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := math.Floor(rank)
+	if int(lower) == len(sorted)-1 {
+		return sorted[len(sorted)-1], nil
+	}
+
+	below, above := sorted[int(lower)], sorted[int(lower)+1]
+	return below + (rank-lower)*(above-below), nil
+}
+
+// normalArgs returns x, the mean and the standard deviation of a call to a function of the normal distribution,
+// whose mean and standard deviation are 0 and 1 unless given, and fails with ErrDomain if the standard deviation isn't positive.
+func normalArgs(args []float64) (x, mean, deviation float64, err error) {
+	x, mean, deviation = args[0], 0, 1
+	if len(args) > 1 {
+		mean = args[1]
+	}
+	if len(args) > 2 {
+		deviation = args[2]
+	}
+	if !positive(deviation) {
+		return 0, 0, 0, ErrDomain
+	}
+	return x, mean, deviation, nil
+}
+
+// normalPDF returns the probability density of the normal distribution at x.
+func normalPDF(args []float64) (float64, error) {
+	x, mean, deviation, err := normalArgs(args)
+	if err != nil {
+		return 0, err
+	}
+
+	z := (x - mean) / deviation
+	return math.Exp(-z*z/2) / (deviation * math.Sqrt(2*math.Pi)), nil
+}
+
+// normalCDF returns the probability that a normally distributed variable is at most x.
+func normalCDF(args []float64) (float64, error) {
+	x, mean, deviation, err := normalArgs(args)
+	if err != nil {
+		return 0, err
+	}
+	return math.Erfc(-(x-mean)/(deviation*math.Sqrt2)) / 2, nil
+}
+
+// normalInverse returns the value x which a normally distributed variable is at most with probability p,
+// which is the inverse of normalCDF, so that norminv(0.975) is about 1.96.
+func normalInverse(args []float64) (float64, error) {
+	p, mean, deviation, err := normalArgs(args)
+	if err != nil {
+		return 0, err
+	}
+	if p <= 0 || p >= 1 {
+		return 0, ErrDomain
+	}
+	return mean + deviation*math.Sqrt2*math.Erfinv(2*p-1), nil
+}
+
+// binomialArgs returns k and the number of trials n and probability of success p of a call to a function of the binomial distribution,
+// and fails with ErrDomain unless n is a natural number and p a probability.
+func binomialArgs(args []float64) (k, n, p float64, err error) {
+	k, n, p = args[0], args[1], args[2]
+	if !naturalNumber(n) || p < 0 || p > 1 {
+		return 0, 0, 0, ErrDomain
+	}
+	return k, n, p, nil
+}
+
+// binomialPDF returns the probability of exactly k successes in n trials which each succeed with probability p,
+// which is 0 unless k is an integer from 0 to n.
+func binomialPDF(args []float64) (float64, error) {
+	k, n, p, err := binomialArgs(args)
+	switch {
+	case err != nil:
+		return 0, err
+	case !naturalNumber(k) || k > n:
+		return 0, nil
+	case p == 0 || p == 1:
+		// certain failures or successes
+		if k == n*p {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	// the binomial coefficient is exact while it is small, and logarithms keep it from overflowing otherwise
+	if ways, _ := combinations([]float64{n, k}); ways <= maxSafeInteger {
+		return ways * math.Pow(p, k) * math.Pow(1-p, n-k), nil
+	}
+	return math.Exp(logCombinations(n, k) + k*math.Log(p) + (n-k)*math.Log1p(-p)), nil
+}
+
+// binomialCDF returns the probability of at most k successes in n trials which each succeed with probability p.
+func binomialCDF(args []float64) (float64, error) {
+	k, n, p, err := binomialArgs(args)
+	k = math.Floor(k)
+	switch {
+	case err != nil:
+		return 0, err
+	case k < 0:
+		return 0, nil
+	case k >= n || p == 0:
+		return 1, nil
+	case p == 1:
+		return 0, nil
+	}
+
+	// the incomplete beta function is only accurate to a few units in the last place,
+	// so while the binomial coefficients are exact the probabilities of each count are summed instead
+	if ways, _ := combinations([]float64{n, math.Floor(n / 2)}); ways <= maxSafeInteger {
+		return binomialSum(k, n, p), nil
+	}
+	return regularizedBeta(n-k, k+1, 1-p), nil
+}
+
+// binomialSum returns the probability of at most k successes in n trials by summing the probabilities of each count,
+// compensating for the rounding of each addition by Neumaier's method.
+func binomialSum(k, n, p float64) float64 {
+	var sum, compensation float64
+	for i := 0.0; i <= k; i++ {
+		term, _ := binomialPDF([]float64{i, n, p})
+
+		total := sum + term
+		if math.Abs(sum) >= math.Abs(term) {
+			compensation += sum - total + term
+		} else {
+			compensation += term - total + sum
+		}
+		sum = total
+	}
+	return sum + compensation
+}
+
+// poissonArgs returns k and the mean rate of a call to a function of the Poisson distribution,
+// and fails with ErrDomain if the rate is negative.
+func poissonArgs(args []float64) (k, rate float64, err error) {
+	k, rate = args[0], args[1]
+	if !nonNegative(rate) {
+		return 0, 0, ErrDomain
+	}
+	return k, rate, nil
+}
+
+// poissonPDF returns the probability of exactly k events where rate are expected, which is 0 unless k is a natural number.
+func poissonPDF(args []float64) (float64, error) {
+	k, rate, err := poissonArgs(args)
+	switch {
+	case err != nil:
+		return 0, err
+	case !naturalNumber(k):
+		return 0, nil
+	case rate == 0:
+		if k == 0 {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	// the direct formula underflows or overflows for large rates and counts, which logarithms don't
+	if result := math.Exp(-rate) * math.Pow(rate, k) / math.Gamma(k+1); result > 0 && result < math.Inf(1) {
+		return result, nil
+	}
+	factorial, _ := math.Lgamma(k + 1)
+	return math.Exp(k*math.Log(rate) - rate - factorial), nil
+}
+
+// poissonCDF returns the probability of at most k events where rate are expected.
+func poissonCDF(args []float64) (float64, error) {
+	k, rate, err := poissonArgs(args)
+	k = math.Floor(k)
+	switch {
+	case err != nil:
+		return 0, err
+	case k < 0:
+		return 0, nil
+	case rate == 0:
+		return 1, nil
+	}
+	return regularizedGammaQ(k+1, rate), nil
+}
+
+// logCombinations returns the natural logarithm of nCr.
+func logCombinations(n, r float64) float64 {
+	all, _ := math.Lgamma(n + 1)
+	chosen, _ := math.Lgamma(r + 1)
+	left, _ := math.Lgamma(n - r + 1)
+	return all - chosen - left
+}
+
+// regularizedGammaQ returns the regularized upper incomplete gamma function Q(a, x) for positive a and x,
+// by its series where it converges quickly and by its continued fraction elsewhere.
+func regularizedGammaQ(a, x float64) float64 {
+	logGamma, _ := math.Lgamma(a)
+	scale := math.Exp(a*math.Log(x) - x - logGamma)
+
+	if x < a+1 {
+		// the series of the lower function P(a, x), which is 1 - Q(a, x)
+		term := 1 / a
+		sum := term
+		for n := 1.0; n < maxIterations && math.Abs(term) > math.Abs(sum)*epsilon; n++ {
+			term *= x / (a + n)
+			sum += term
+		}
+		return 1 - sum*scale
+	}
+
+	// the continued fraction of Q(a, x), evaluated by Lentz's method
+	b := x + 1 - a
+	c, d := 1/tiny, 1/b
+	h := d
+	for i := 1.0; i < maxIterations; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = nonZero(an*d + b)
+		c = nonZero(b + an/c)
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return scale * h
+}
+
+// regularizedBeta returns the regularized incomplete beta function I_x(a, b) for positive a and b and x from 0 to 1,
+// by its continued fraction for x or, where that converges slowly, for 1 - x by the symmetry I_x(a, b) = 1 - I_1-x(b, a).
+func regularizedBeta(a, b, x float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	}
+
+	logA, _ := math.Lgamma(a)
+	logB, _ := math.Lgamma(b)
+	logAB, _ := math.Lgamma(a + b)
+	scale := math.Exp(logAB - logA - logB + a*math.Log(x) + b*math.Log1p(-x))
+
+	if x < (a+1)/(a+b+2) {
+		return scale * betaFraction(a, b, x) / a
+	}
+	return 1 - scale*betaFraction(b, a, 1-x)/b
+}
+
+// betaFraction evaluates the continued fraction of the incomplete beta function by Lentz's method.
+func betaFraction(a, b, x float64) float64 {
+	c, d := 1.0, 1/nonZero(1-(a+b)*x/(a+1))
+	h := d
+	for m := 1.0; m < maxIterations; m++ {
+		// the even and odd steps of the fraction
+		even := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 / nonZero(1+even*d)
+		c = nonZero(1 + even/c)
+		h *= d * c
+
+		odd := -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 / nonZero(1+odd*d)
+		c = nonZero(1 + odd/c)
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// nonZero replaces numbers too close to zero to divide by with tiny.
+func nonZero(x float64) float64 {
+	if math.Abs(x) < tiny {
+		return tiny
+	}
+	return x
+}
@@ -0,0 +1,171 @@
+package solver
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse_factorials(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"factorial", "5!", "(5!)"},
+		{"negative factorial", "-3!", "(-(3!))"},
+		{"factorial exponent", "2^3!", "(2 ^ (3!))"},
+		{"power of factorial", "3!^2", "((3!) ^ 2)"},
+		{"double factorial", "3!!", "((3!)!)"},
+		{"factorial of index", "xs[0]!", "(xs[0]!)"},
+		{"factorial of group", "(n - 1)! * n", "((((n - 1))!) * n)"},
+		{"inequality", "n! != 2", "((n!) != 2)"},
+		{"inequality after operand", "4!=24", "(4 != 24)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestSolve_combinatorics(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantResult string
+	}{
+		{"factorial", "5!", nil, "120"},
+		{"factorial of zero", "0!", nil, "1"},
+		{"negative factorial", "-3!", nil, "-6"},
+		{"factorial exponent", "2^3!", nil, "64"},
+		{"inequality rather than factorial", "4!=24", nil, "true"},
+		{"comparison of factorial", "4! == 24", nil, "true"},
+		{"combinations", "nCr(52, 5)", nil, "2598960"},
+		{"too many combinations", "nCr(3, 5)", nil, "0"},
+		{"permutations", "nPr(10, 3)", nil, "720"},
+		{"no permutations of many", "nPr(1e17, 0)", nil, "1"},
+		{"permutations of many", "nPr(1e17, 2)", nil, "10000000000000000000000000000000000"},
+		{"gamma", "gamma(5) == 4!", nil, "true"},
+		{"percentile", "percentile([1, 2, 3, 4], 25)", nil, "1.75"},
+		{"median percentile", "percentile(1..10, 50) == median(1..10)", nil, "true"},
+		{"binomial", "binopdf(3, 10, 0.5)", nil, "0.1171875"},
+		{"cumulative binomial", "binocdf(3, 10, 0.5)", nil, "0.171875"},
+		{"cumulative binomial of quarters", "binocdf(2, 4, 0.25)", nil, "0.94921875"},
+		{"standard normal", "normcdf(0) + normpdf(0, 0, 1) * 0", nil, "0.5"},
+		{"integer factorial", "30!", []Option{WithInteger()}, "265252859812191058636308480000000"},
+		{"integer combinations", "nCr(100, 50)", []Option{WithInteger()}, "100891344545564193334812497256"},
+		{"rational permutations", "nPr(20, 10) / 2", []Option{WithRational(Fraction, RejectInexact)}, "335221286400"},
+		{"rational factorial", "5! / 7", []Option{WithRational(Fraction, RejectInexact)}, "120/7"},
+		{"rational percentile", "percentile([1/3, 2/3], 50)", []Option{WithRational(Fraction, RejectInexact)}, "1/2"},
+		{"units percentile", "percentile([3 m, 1 km, 20 cm], 50)", []Option{WithUnits()}, "3 m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_combinatoricsErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+	}{
+		{"factorial of fraction", "2.5!", nil, ErrDomain},
+		{"factorial overflow", "171!", nil, ErrOverflow},
+		{"factorial of string", `"a"!`, nil, ErrType},
+		{"negative combinations", "nCr(-1, 1)", nil, ErrDomain},
+		{"fractional permutations", "nPr(5, 1.5)", nil, ErrDomain},
+		{"gamma of negative integer", "gamma(-2)", nil, ErrDomain},
+		{"percentile of nothing", "percentile([], 50)", nil, ErrDomain},
+		{"percentile above 100", "percentile([1, 2], 101)", nil, ErrDomain},
+		{"list of percentages", "percentile([1, 2, 3, 4], [25, 75])", nil, ErrType},
+		{"percentage before numbers", "percentile(50, [1, 2, 3])", nil, ErrType},
+		{"rational list of percentages", "percentile([1, 2, 3, 4], [25, 75])", []Option{WithRational(Fraction, RejectInexact)}, ErrType},
+		{"zero deviation", "normpdf(1, 0, 0)", nil, ErrDomain},
+		{"inverse of certainty", "norminv(1)", nil, ErrDomain},
+		{"probability above 1", "binocdf(1, 10, 1.5)", nil, ErrDomain},
+		{"fractional trials", "binopdf(1, 2.5, 0.5)", nil, ErrDomain},
+		{"negative rate", "poisspdf(1, -1)", nil, ErrDomain},
+		{"integer factorial of negative", "(-1)!", []Option{WithInteger()}, ErrDomain},
+		{"integer combinations overflow", "nCr(2^40, 2^20)", []Option{WithInteger()}, ErrOverflow},
+		{"rational factorial of fraction", "(1/2)!", []Option{WithRational(Fraction, RejectInexact)}, ErrDomain},
+		{"rational percentile of nothing", "percentile([], 50)", []Option{WithRational(Fraction, RejectInexact)}, ErrDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) || !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_distributions(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func([]float64) (float64, error)
+		args []float64
+		want float64
+	}{
+		{"normpdf", normalPDF, []float64{1}, 0.24197072451914337},
+		{"normpdf of scaled", normalPDF, []float64{110, 100, 15}, 0.02129653370149015},
+		{"normcdf", normalCDF, []float64{1.96}, 0.9750021048517795},
+		{"normcdf of scaled", normalCDF, []float64{70, 100, 15}, 0.022750131948179195},
+		{"norminv", normalInverse, []float64{0.975}, 1.959963984540054},
+		{"norminv of scaled", normalInverse, []float64{0.5, 100, 15}, 100},
+		{"binopdf", binomialPDF, []float64{2, 5, 0.3}, 0.3087},
+		{"binopdf of fraction", binomialPDF, []float64{2.5, 5, 0.3}, 0},
+		{"binopdf of certainty", binomialPDF, []float64{5, 5, 1}, 1},
+		{"binocdf", binomialCDF, []float64{5, 10, 0.5}, 0.623046875},
+		{"binocdf of many trials", binomialCDF, []float64{500, 1000, 0.5}, 0.5126125090891804},
+		{"binocdf below 0", binomialCDF, []float64{-1, 10, 0.5}, 0},
+		{"binocdf of all", binomialCDF, []float64{10, 10, 0.5}, 1},
+		{"poisspdf", poissonPDF, []float64{2, 3}, 0.22404180765538775},
+		{"poisspdf of large rate", poissonPDF, []float64{1000, 1000}, 0.0126146113487215},
+		{"poisspdf of no rate", poissonPDF, []float64{0, 0}, 1},
+		{"poisscdf", poissonCDF, []float64{2, 3}, 0.42319008112684353},
+		{"poisscdf of large rate", poissonCDF, []float64{1000, 1000}, 0.508409367168506},
+		{"poisscdf of fraction", poissonCDF, []float64{2.5, 3}, 0.42319008112684353},
+		{"percentile", percentile, []float64{15, 20, 35, 40, 50, 40}, 29},
+		{"percentile of one", percentile, []float64{7, 100}, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(tt.args)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			// the series and continued fractions are accurate to a few units in the last place
+			if math.Abs(got-tt.want) > 1e-12*math.Max(1, math.Abs(tt.want)) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", got, tt.want)
+			}
+		})
+	}
+}
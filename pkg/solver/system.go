@@ -0,0 +1,678 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// numberSystem is a set of numbers other than float64 which expressions can be evaluated in,
+// along with the arithmetic on them. Numbers of a system are passed around as interface{}
+// and are never modified once created.
+type numberSystem interface {
+	// literal converts the text of a number literal.
+	literal(text string) (interface{}, error)
+	// fromFloat converts the float64 value of a variable.
+	fromFloat(x float64) (interface{}, error)
+	// constant returns the value of one of the named constants.
+	constant(name string) (interface{}, error)
+	// owns reports whether x is a number of the system, as stored in an Env by an earlier evaluation.
+	owns(x interface{}) bool
+
+	// unary applies a prefix operator, and operate an infix one.
+	// Operators a system doesn't implement are reported by unsupportedOperator.
+	unary(op string, x interface{}) (interface{}, error)
+	operate(op string, left, right interface{}) (interface{}, error)
+	// compare applies one of the comparison operators, such as < or ==.
+	compare(op string, left, right interface{}) (bool, error)
+	// call applies a library function to its arguments.
+	call(name string, fn function, args []interface{}) (interface{}, error)
+
+	// float approximates a number as a float64, and integer returns its exact value if it is an integer.
+	float(x interface{}) float64
+	integer(x interface{}) (*big.Int, bool)
+	// format returns the text of a number as shown in results.
+	format(x interface{}) string
+}
+
+// approximator is implemented by number systems whose exact numbers can be approximated,
+// such as rational numbers by floating-point ones.
+type approximator interface {
+	// isApproximation reports whether x is an approximation.
+	isApproximation(x interface{}) bool
+}
+
+// constantSystem is implemented by number systems with constants of their own, such as the imaginary unit.
+type constantSystem interface {
+	// hasConstant reports whether the system defines a constant which isn't one of constants.
+	hasConstant(name string) bool
+}
+
+// hasConstant reports whether a number system defines a constant of its own with the given name.
+func hasConstant(system numberSystem, name string) bool {
+	s, ok := system.(constantSystem)
+	return ok && s.hasConstant(name)
+}
+
+// checkPower fails with the error every number system shares for raising a base to a power,
+// which it reports before computing the power in its own way: a negative power of zero is the reciprocal of zero.
+func checkPower(zeroBase, negativeExp bool) error {
+	if zeroBase && negativeExp {
+		return ErrDivisionByZero
+	}
+	return nil
+}
+
+// exactPowerFits reports whether an exact number of the given number of bits raised to the power of n,
+// such as an integer or the numerator and denominator of a fraction, stays within maxExactBits.
+func exactPowerFits(bits int, n *big.Int) bool {
+	return n.IsInt64() && float64(bits)*float64(n.Int64()) <= maxExactBits
+}
+
+// evaluateIn computes the value of the abstract syntax tree parsed from expr in a number system,
+// with the numbers written in it converted by convertLiterals,
+// resolving names from env and storing assignments and function definitions in it.
+// A nil env is replaced with an empty one.
+// The places where exact numbers were first approximated are returned as warnings.
+func evaluateIn(system numberSystem, expr string, node Node, literals literals, env *Env, cfg *config) (interface{}, []*EvalError, error) {
+	if env == nil {
+		env = &Env{}
+	}
+
+	var undefined []*IdentNode
+	for _, ident := range undefinedNames(node, env) {
+		if !hasConstant(system, ident.Name) {
+			undefined = append(undefined, ident)
+		}
+	}
+	if len(undefined) > 0 {
+		return nil, nil, newUndefinedEvalError(expr, undefined)
+	}
+
+	var warnings []*EvalError
+	e := &systemEvaluator{system: system, expr: expr, literals: literals, globals: env, cfg: cfg, warnings: &warnings}
+
+	value, err := e.eval(node)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, warnings, nil
+}
+
+// literal is a number written in an expression as a number of a system, or the error converting it.
+type literal struct {
+	x   interface{}
+	err error
+}
+
+// literals holds the numbers written in an expression, so that programs convert them once rather than on every evaluation.
+type literals map[*NumberNode]literal
+
+// convertLiterals converts the numbers written in the tree parsed from expr to numbers of a system.
+// Errors such as ErrNotInteger are kept to be reported when the number is evaluated.
+func convertLiterals(system numberSystem, expr string, node Node) literals {
+	converted := make(literals)
+	walk(node, func(node Node) {
+		if number, ok := node.(*NumberNode); ok {
+			x, err := system.literal(expr[number.Pos():number.End()])
+			converted[number] = literal{x: x, err: err}
+		}
+	})
+	return converted
+}
+
+// systemEvaluator computes the values of nodes parsed from an expression in a number system.
+type systemEvaluator struct {
+	system numberSystem
+	expr   string
+	// literals holds the converted numbers of the program being evaluated.
+	// The numbers of functions defined by other programs aren't in it, and are converted when they are evaluated.
+	literals literals
+	// globals is the scope of the whole program, and args the arguments of the function being evaluated.
+	globals   *Env
+	args      map[string]interface{}
+	cfg       *config
+	callDepth int
+	// warnings collects approximations. It is nil in the bodies of functions,
+	// whose approximations are reported at their calls instead.
+	warnings *[]*EvalError
+}
+
+// noteApproximation records a warning if the result of a node is an approximation
+// while none of the operands it was computed from are.
+func (e *systemEvaluator) noteApproximation(node Node, result interface{}, operands ...interface{}) {
+	system, ok := e.system.(approximator)
+	if !ok || e.warnings == nil || !system.isApproximation(result) {
+		return
+	}
+
+	for _, operand := range operands {
+		if system.isApproximation(operand) {
+			return
+		}
+	}
+
+	warning := newEvalError(e.expr, ErrNotRational, node)
+	warning.Msg = fmt.Sprintf("%q is not rational and was approximated", e.expr[node.Pos():node.End()])
+	*e.warnings = append(*e.warnings, warning)
+}
+
+// eval computes the value of a node.
+func (e *systemEvaluator) eval(node Node) (interface{}, error) {
+	switch node := node.(type) {
+	case *NumberNode:
+		converted, ok := e.literals[node]
+		if !ok {
+			converted.x, converted.err = e.system.literal(e.expr[node.Pos():node.End()])
+		}
+
+		value, err := converted.x, converted.err
+		if err != nil {
+			return nil, newEvalError(e.expr, err, node)
+		}
+		return value, nil
+
+	case *BoolNode:
+		return Bool(node.Value), nil
+
+	case *StringNode:
+		return String(node.Value), nil
+
+	case *ListNode:
+		list := make(List, len(node.Elements))
+		for i, element := range node.Elements {
+			value, err := e.eval(element)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = valueIn(e.system, value)
+		}
+		return list, nil
+
+	case *IndexNode:
+		return e.index(node)
+
+	case *IdentNode:
+		return e.lookup(node)
+
+	case *GroupNode:
+		return e.eval(node.Expr)
+
+	case *AssignNode:
+		value, err := e.eval(node.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if v, ok := value.(Value); ok {
+			e.globals.SetValue(node.Name, v)
+		} else {
+			e.globals.setExact(node.Name, e.system.float(value), value)
+		}
+		return value, nil
+
+	case *ProgramNode:
+		var value interface{}
+		for _, statement := range node.Statements {
+			var err error
+			value, err = e.eval(statement)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+
+	case *UnaryNode:
+		operand, err := e.eval(node.Operand)
+		if err != nil {
+			return nil, err
+		}
+
+		var result interface{}
+		b, isBool := operand.(Bool)
+		switch _, isOther := operand.(Value); {
+		case node.Op == not && isBool:
+			result = !b
+		case node.Op == not, isOther:
+			err = &TypeError{Op: node.Op, Left: typeOf(operand)}
+		default:
+			result, err = e.system.unary(node.Op, operand)
+		}
+		if err != nil {
+			return nil, newEvalError(e.expr, err, node)
+		}
+
+		e.noteApproximation(node, result, operand)
+		return result, nil
+
+	case *PercentNode:
+		operand, err := e.eval(node.Operand)
+		if err != nil {
+			return nil, err
+		}
+
+		var result interface{}
+		if _, ok := operand.(Value); ok {
+			err = &TypeError{Op: percent, Left: typeOf(operand)}
+		} else {
+			result, err = percentage(e.system, divide, operand)
+		}
+		if err != nil {
+			return nil, newEvalError(e.expr, err, node)
+		}
+
+		e.noteApproximation(node, result, operand)
+		return result, nil
+
+	case *FactorialNode:
+		operand, err := e.eval(node.Operand)
+		if err != nil {
+			return nil, err
+		}
+
+		var result interface{}
+		if _, ok := operand.(Value); ok {
+			err = &TypeError{Op: factorialName, Left: typeOf(operand)}
+		} else {
+			result, err = callIn(e.system, factorialName, functions[factorialName], []interface{}{operand})
+		}
+		if err != nil {
+			return nil, newEvalError(e.expr, err, node)
+		}
+
+		e.noteApproximation(node, result, operand)
+		return result, nil
+
+	case *BinaryNode:
+		if node.Op == and || node.Op == or {
+			return e.logical(node)
+		}
+
+		op, leftNode, rightNode := operands(node)
+		left, err := e.eval(leftNode)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := e.eval(rightNode)
+		if err != nil {
+			return nil, err
+		}
+
+		var result interface{}
+		_, leftOther := left.(Value)
+		_, rightOther := right.(Value)
+		switch {
+		case leftOther || rightOther:
+			result, err = operateOthers(systemScalars{e.system}, op, valueIn(e.system, left), valueIn(e.system, right))
+		case isComparison(op):
+			var b bool
+			b, err = e.system.compare(op, left, right)
+			result = Bool(b)
+		default:
+			result, err = e.operate(op, left, right)
+		}
+		if err != nil {
+			return nil, newOperatorEvalError(e.expr, err, node)
+		}
+
+		e.noteApproximation(node, result, left, right)
+		return result, nil
+
+	case *ConditionalNode:
+		cond, err := e.eval(node.Cond)
+		if err != nil {
+			return nil, err
+		}
+
+		b, ok := cond.(Bool)
+		if !ok {
+			return nil, newEvalError(e.expr, &TypeError{Op: question, Left: typeOf(cond)}, node.Cond)
+		}
+		if b {
+			return e.eval(node.Then)
+		}
+		return e.eval(node.Else)
+
+	case *CallNode:
+		return e.call(node)
+
+	case *FuncDefNode:
+		// definitions have a value of zero
+		e.globals.define(node, e.expr)
+		return e.system.fromFloat(0)
+	}
+
+	return nil, newEvalError(e.expr, ErrMalformedExp, node)
+}
+
+// operate applies an arithmetic operator to two numbers of the system.
+func (e *systemEvaluator) operate(op string, left, right interface{}) (interface{}, error) {
+	switch op {
+	case addPercent, subtractPercent, multiplyPercent, dividePercent, percentOf, asPercentOf:
+		return operatePercent(e.system, op, left, right)
+	case listRange:
+		return rangeIn(e.system, left, right)
+	case multiplyElements:
+		return e.system.operate(multiply, left, right)
+	}
+	return e.system.operate(op, left, right)
+}
+
+// logical computes the value of && or ||, whose right operand is only evaluated
+// if the left one doesn't decide the result on its own.
+func (e *systemEvaluator) logical(node *BinaryNode) (interface{}, error) {
+	left, err := e.eval(node.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := left.(Bool)
+	if !ok {
+		return nil, newOperatorEvalError(e.expr, &TypeError{Op: node.Op, Left: typeOf(left)}, node)
+	}
+	if b == (node.Op == or) {
+		return b, nil
+	}
+
+	right, err := e.eval(node.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := right.(Bool); !ok {
+		return nil, newOperatorEvalError(e.expr, &TypeError{Op: node.Op, Left: BoolType, Right: typeOf(right)}, node)
+	}
+	return right, nil
+}
+
+// typeOf returns the type of a number of a system or of another value.
+func typeOf(x interface{}) Type {
+	if v, ok := x.(Value); ok {
+		return v.Type()
+	}
+	return NumberType
+}
+
+// valueIn returns a number of a system as a Value keeping its exact value, or any other value as it is.
+func valueIn(system numberSystem, x interface{}) Value {
+	if v, ok := x.(Value); ok {
+		return v
+	}
+	return systemNumber{system: system, x: x}
+}
+
+// numberIn returns a Value held in a list or an Env with its numbers as numbers of a system.
+// Numbers of other systems are converted through their float64 approximations.
+func numberIn(system numberSystem, v Value) (interface{}, error) {
+	switch {
+	case v.Type() != NumberType:
+		return v, nil
+	case isSystemNumber(v, system):
+		return v.(systemNumber).x, nil
+	}
+	return system.fromFloat(approximate(v))
+}
+
+// isSystemNumber reports whether v is a number of the given system.
+func isSystemNumber(v Value, system numberSystem) bool {
+	n, ok := v.(systemNumber)
+	return ok && system.owns(n.x)
+}
+
+// index computes the element of a list at an index.
+func (e *systemEvaluator) index(node *IndexNode) (interface{}, error) {
+	operand, err := e.eval(node.Operand)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := e.eval(node.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	var element interface{}
+	list, ok := operand.(List)
+	_, isOther := index.(Value)
+	switch {
+	case !ok:
+		err = &TypeError{Op: indexing, Left: typeOf(operand)}
+	case isOther:
+		err = &TypeError{Op: indexing, Left: ListType, Right: typeOf(index)}
+	default:
+		var v Value
+		if v, err = elementAt(list, e.system.float(index)); err == nil {
+			element, err = numberIn(e.system, v)
+		}
+	}
+	if err != nil {
+		return nil, newEvalError(e.expr, err, node)
+	}
+	return element, nil
+}
+
+// arguments returns the numbers a library function is applied to, which are its arguments
+// with lists given to aggregate functions replaced by their elements.
+func (e *systemEvaluator) arguments(name string, fn function, args []interface{}) ([]interface{}, error) {
+	nums := make([]interface{}, 0, len(args))
+	for i, arg := range args {
+		list, isList := arg.(List)
+		_, isOther := arg.(Value)
+		switch {
+		case isList && fn.spreads(i, len(args)):
+			for _, element := range list {
+				if element.Type() != NumberType {
+					return nil, &TypeError{Op: name, Left: element.Type()}
+				}
+				num, err := numberIn(e.system, element)
+				if err != nil {
+					return nil, err
+				}
+				nums = append(nums, num)
+			}
+		case isOther:
+			return nil, &TypeError{Op: name, Left: typeOf(arg)}
+		default:
+			nums = append(nums, arg)
+		}
+	}
+	return nums, nil
+}
+
+// lookup returns the value of a name, which is an argument of the function being evaluated,
+// a variable or a constant, in that order.
+func (e *systemEvaluator) lookup(node *IdentNode) (interface{}, error) {
+	if value, ok := e.args[node.Name]; ok {
+		return value, nil
+	}
+
+	// variables assigned in the same number system keep their exact value, and other values keep their type
+	if value, ok := e.globals.exact[node.Name]; ok && e.system.owns(value) {
+		return value, nil
+	}
+	if value, ok := e.globals.exact[node.Name].(Value); ok {
+		return value, nil
+	}
+
+	if value, ok := e.globals.Get(node.Name); ok {
+		result, err := e.system.fromFloat(value)
+		if err != nil {
+			return nil, newEvalError(e.expr, err, node)
+		}
+		return result, nil
+	}
+
+	if _, ok := constants[node.Name]; !ok && !hasConstant(e.system, node.Name) {
+		return nil, newUndefinedEvalError(e.expr, []*IdentNode{node})
+	}
+
+	value, err := e.system.constant(node.Name)
+	if err != nil {
+		return nil, newEvalError(e.expr, err, node)
+	}
+
+	// constants such as pi can only be approximated by some systems, unlike variables,
+	// whose approximations are noted where they are computed
+	e.noteApproximation(node, value)
+	return value, nil
+}
+
+// call computes the value of a call to a user-defined or library function.
+func (e *systemEvaluator) call(node *CallNode) (interface{}, error) {
+	args := make([]interface{}, len(node.Args))
+	for i, arg := range node.Args {
+		value, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	result, err := e.callFunction(node, args)
+	if err != nil {
+		return nil, err
+	}
+
+	e.noteApproximation(node, result, args...)
+	return result, nil
+}
+
+// callFunction applies a user-defined or library function to the values of its arguments.
+func (e *systemEvaluator) callFunction(node *CallNode, args []interface{}) (interface{}, error) {
+	fn, userFn, err := resolveFunction(e.expr, node, e.globals)
+	if err != nil {
+		return nil, err
+	}
+
+	if userFn == nil && fn.matrix != nil {
+		values := make([]Value, len(args))
+		for i, arg := range args {
+			values[i] = valueIn(e.system, arg)
+		}
+
+		result, err := callMatrixFunction(e.expr, node, fn, systemScalars{e.system}, values)
+		if err != nil {
+			return nil, err
+		}
+		return numberIn(e.system, result)
+	}
+
+	if userFn == nil {
+		var result interface{}
+		nums, err := e.arguments(node.Name, fn, args)
+		if err == nil {
+			result, err = callIn(e.system, node.Name, fn, nums)
+		}
+		if err != nil {
+			return nil, newEvalError(e.expr, err, node)
+		}
+		return result, nil
+	}
+
+	if e.callDepth >= e.cfg.maxCallDepth {
+		return nil, newEvalError(e.expr, ErrCallDepthExceeded, node)
+	}
+
+	body := &systemEvaluator{
+		system:    e.system,
+		expr:      userFn.source,
+		literals:  e.literals,
+		globals:   e.globals,
+		args:      make(map[string]interface{}, len(args)),
+		cfg:       e.cfg,
+		callDepth: e.callDepth + 1,
+	}
+	for i, param := range userFn.params {
+		body.args[param] = args[i]
+	}
+
+	value, err := body.eval(userFn.body)
+	if err != nil {
+		return nil, newCallEvalError(e.expr, err.(*EvalError), node)
+	}
+	return value, nil
+}
+
+// floatInteger returns the exact value of a float64 if it is an integer.
+func floatInteger(x float64) (*big.Int, bool) {
+	if math.IsInf(x, 0) || x != math.Trunc(x) {
+		return nil, false
+	}
+
+	n, _ := big.NewFloat(x).Int(nil)
+	return n, true
+}
+
+// callIn applies a library function to numbers of a system. Statistical functions such as sum and avg
+// are computed with the arithmetic of the system, and the others by the system's call.
+func callIn(system numberSystem, name string, fn function, args []interface{}) (interface{}, error) {
+	switch name {
+	case "sum", "product", "count", "avg", "median", "stddev", "percentile":
+		return aggregate(system, name, args)
+	}
+
+	if len(args) == 0 {
+		// the minimum or maximum of empty lists
+		return nil, ErrDomain
+	}
+	return system.call(name, fn, args)
+}
+
+// callFloat applies a library function to numbers of a system by converting them to float64 and back.
+// It is used for functions which a system has no exact implementation of.
+func callFloat(system numberSystem, fn function, args []interface{}) (interface{}, error) {
+	floats := make([]float64, len(args))
+	for i, arg := range args {
+		floats[i] = system.float(arg)
+	}
+
+	result, err := fn.call(floats)
+	if err == nil {
+		err = checkFinite(result, ErrDomain, floats...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return system.fromFloat(result)
+}
+
+// percentage multiplies or divides a number by a hundred with the operations of a number system.
+func percentage(system numberSystem, op string, x interface{}) (interface{}, error) {
+	hundred, err := system.literal("100")
+	if err != nil {
+		return nil, err
+	}
+	return system.operate(op, x, hundred)
+}
+
+// operatePercent applies an operator involving a percentage with the operations of a number system,
+// multiplying before dividing so that exact systems stay exact.
+func operatePercent(system numberSystem, op string, left, right interface{}) (interface{}, error) {
+	if op == dividePercent || op == asPercentOf {
+		// a / p% and a as % of b are both a * 100 / b
+		scaled, err := percentage(system, multiply, left)
+		if err != nil {
+			return nil, err
+		}
+		return system.operate(divide, scaled, right)
+	}
+
+	product, err := system.operate(multiply, left, right)
+	if err != nil {
+		return nil, err
+	}
+	part, err := percentage(system, divide, product)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case addPercent:
+		return system.operate(add, left, part)
+	case subtractPercent:
+		return system.operate(subtract, left, part)
+	default:
+		return part, nil
+	}
+}
@@ -0,0 +1,549 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// baseUnits are the units of the SI base quantities: mass, length, time, electric current,
+// temperature, amount of substance and luminous intensity.
+var baseUnits = [...]string{"kg", "m", "s", "A", "K", "mol", "cd"}
+
+// dimension holds the exponents of the base quantities of a quantity, in the order of baseUnits.
+type dimension [len(baseUnits)]int
+
+// the dimensions of the named units
+var (
+	dimensionless  = dimension{}
+	dimMass        = dimension{1}
+	dimLength      = dimension{0, 1}
+	dimDuration    = dimension{0, 0, 1}
+	dimCurrent     = dimension{0, 0, 0, 1}
+	dimTemperature = dimension{0, 0, 0, 0, 1}
+	dimSubstance   = dimension{0, 0, 0, 0, 0, 1}
+	dimLuminosity  = dimension{0, 0, 0, 0, 0, 0, 1}
+	dimArea        = dimension{0, 2}
+	dimVolume      = dimension{0, 3}
+	dimSpeed       = dimension{0, 1, -1}
+	dimFrequency   = dimension{0, 0, -1}
+	dimForce       = dimension{1, 1, -2}
+	dimPressure    = dimension{1, -1, -2}
+	dimEnergy      = dimension{1, 2, -2}
+	dimPower       = dimension{1, 2, -3}
+	dimCharge      = dimension{0, 0, 1, 1}
+	dimVoltage     = dimension{1, 2, -3, -1}
+	dimResistance  = dimension{1, 2, -3, -2}
+	dimCapacitance = dimension{-1, -2, 4, 2}
+)
+
+// plus returns the dimension of the product of a quantity of dimension d
+// and one of dimension other raised to the power of n.
+func (d dimension) plus(other dimension, n int) dimension {
+	for i := range d {
+		d[i] += other[i] * n
+	}
+	return d
+}
+
+// power returns the dimension of a quantity of dimension d raised to the power of exp,
+// and false if that isn't a dimension, as the exponents of the square root of a length aren't integers.
+func (d dimension) power(exp float64) (dimension, bool) {
+	for i, n := range d {
+		p := float64(n) * exp
+		if p != math.Trunc(p) || math.Abs(p) > math.MaxInt32 {
+			return d, false
+		}
+		d[i] = int(p)
+	}
+	return d, true
+}
+
+// units returns the base units of the dimension.
+func (d dimension) units() []unitPower {
+	var units []unitPower
+	for i, n := range d {
+		if n != 0 {
+			units = append(units, unitPower{name: baseUnits[i], power: n})
+		}
+	}
+	return units
+}
+
+// String writes the dimension in base units, such as kg*m/s^2, or 1 for dimensionless quantities.
+func (d dimension) String() string {
+	if d == dimensionless {
+		return "1"
+	}
+	return formatUnits(d.units())
+}
+
+// unit is a named unit of measurement.
+type unit struct {
+	// scale is the size of the unit in the coherent SI unit of its dimension,
+	// which is 1000 for the km and 0.001 for the g, as the coherent unit of mass is the kg.
+	scale float64
+	dim   dimension
+	// prefixed reports whether the unit can be preceded by an SI prefix such as k or m.
+	prefixed bool
+}
+
+// units are the named units. Names of variables take precedence over them.
+var units = map[string]unit{
+	// base units
+	"g":   {1e-3, dimMass, true},
+	"m":   {1, dimLength, true},
+	"s":   {1, dimDuration, true},
+	"A":   {1, dimCurrent, true},
+	"K":   {1, dimTemperature, true},
+	"mol": {1, dimSubstance, true},
+	"cd":  {1, dimLuminosity, true},
+
+	// named derived units
+	"Hz":  {1, dimFrequency, true},
+	"N":   {1, dimForce, true},
+	"Pa":  {1, dimPressure, true},
+	"J":   {1, dimEnergy, true},
+	"W":   {1, dimPower, true},
+	"C":   {1, dimCharge, true},
+	"V":   {1, dimVoltage, true},
+	"ohm": {1, dimResistance, true},
+	"Ω":   {1, dimResistance, true},
+	"F":   {1, dimCapacitance, true},
+	"rad": {1, dimensionless, false},
+
+	// units accepted for use with the SI
+	"min": {60, dimDuration, false},
+	"h":   {3600, dimDuration, false},
+	"day": {86400, dimDuration, false},
+	"L":   {1e-3, dimVolume, true},
+	"l":   {1e-3, dimVolume, true},
+	"t":   {1000, dimMass, false},
+	"ha":  {1e4, dimArea, false},
+	"deg": {math.Pi / 180, dimensionless, false},
+	"eV":  {1.602176634e-19, dimEnergy, true},
+	"Wh":  {3600, dimEnergy, true},
+	"bar": {1e5, dimPressure, true},
+
+	// other common units
+	"week": {604800, dimDuration, false},
+	"yr":   {31557600, dimDuration, false},
+	"inch": {0.0254, dimLength, false},
+	"ft":   {0.3048, dimLength, false},
+	"yd":   {0.9144, dimLength, false},
+	"mi":   {1609.344, dimLength, false},
+	"nmi":  {1852, dimLength, false},
+	"lb":   {0.45359237, dimMass, false},
+	"oz":   {0.028349523125, dimMass, false},
+	"mph":  {0.44704, dimSpeed, false},
+	"kn":   {1852.0 / 3600, dimSpeed, false},
+	"cal":  {4.184, dimEnergy, true},
+	"atm":  {101325, dimPressure, false},
+	"psi":  {6894.757293168361, dimPressure, false},
+}
+
+// prefixes are the SI prefixes of units, with the factors they multiply them by.
+var prefixes = []struct {
+	symbol string
+	factor float64
+}{
+	{"Y", 1e24}, {"Z", 1e21}, {"E", 1e18}, {"P", 1e15}, {"T", 1e12}, {"G", 1e9}, {"M", 1e6},
+	{"k", 1e3}, {"h", 1e2}, {"da", 1e1}, {"d", 1e-1}, {"c", 1e-2}, {"m", 1e-3},
+	{"µ", 1e-6}, {"μ", 1e-6}, {"u", 1e-6}, {"n", 1e-9}, {"p", 1e-12},
+	{"f", 1e-15}, {"a", 1e-18}, {"z", 1e-21}, {"y", 1e-24},
+}
+
+// namedUnits are the units results are written in when their dimension matches,
+// instead of the product of the units they were computed from.
+var namedUnits = []string{"kg", "m", "s", "A", "K", "mol", "cd", "Hz", "N", "Pa", "J", "W", "C", "V", "ohm", "F"}
+
+// lookupUnit returns the unit with the given name, which is either one of units or a prefixed one such as km.
+// Names of units are preferred to prefixed ones.
+func lookupUnit(name string) (unit, bool) {
+	if u, ok := units[name]; ok {
+		return u, true
+	}
+
+	for _, prefix := range prefixes {
+		if u, ok := units[strings.TrimPrefix(name, prefix.symbol)]; ok && u.prefixed && strings.HasPrefix(name, prefix.symbol) {
+			u.scale *= prefix.factor
+			return u, true
+		}
+	}
+	return unit{}, false
+}
+
+// unitPower is a unit raised to a power, such as the s^-2 of m/s^2.
+type unitPower struct {
+	name  string
+	power int
+}
+
+// scale returns the size of a product of units in the coherent SI unit of their dimension.
+func scale(units []unitPower) float64 {
+	result := 1.0
+	for _, u := range units {
+		unit, _ := lookupUnit(u.name)
+		result *= math.Pow(unit.scale, float64(u.power))
+	}
+	return result
+}
+
+// formatUnits writes a product of units such as kg*m/s^2, with units of negative powers after a slash
+// unless there are no others, as in s^-1.
+func formatUnits(units []unitPower) string {
+	var numerator, denominator []string
+	for _, u := range units {
+		switch {
+		case u.power == 1:
+			numerator = append(numerator, u.name)
+		case u.power > 0 || len(units) == 1:
+			numerator = append(numerator, u.name+power+strconv.Itoa(u.power))
+		case u.power == -1:
+			denominator = append(denominator, u.name)
+		default:
+			denominator = append(denominator, u.name+power+strconv.Itoa(-u.power))
+		}
+	}
+
+	switch {
+	case len(denominator) == 0:
+		return strings.Join(numerator, multiply)
+	case len(numerator) == 0:
+		numerator = []string{"1"}
+	}
+
+	text := strings.Join(numerator, multiply) + divide
+	if len(denominator) > 1 {
+		return text + openParenthesis + strings.Join(denominator, multiply) + closeParenthesis
+	}
+	return text + denominator[0]
+}
+
+// quantity is a number of units mode, such as 9.81 m/s^2.
+type quantity struct {
+	// value is the magnitude in the coherent SI unit of the dimension, so that 3 km has a value of 3000.
+	value float64
+	dim   dimension
+	// units are the units the quantity is written in, in order of first appearance.
+	units []unitPower
+	// converted reports whether the units were chosen with a conversion, in which case they are kept as they are.
+	converted bool
+}
+
+// times returns the product of q and other raised to the power of n, which is 1 or -1.
+// Units of the same dimension are combined into the first one, so that km*m is km^2.
+func (q quantity) times(other quantity, n int) quantity {
+	result := quantity{value: q.value, dim: q.dim.plus(other.dim, n)}
+	result.units = append(result.units, q.units...)
+
+	for _, u := range other.units {
+		u.power *= n
+		if i := result.matchUnit(u.name); i >= 0 {
+			result.units[i].power += u.power
+		} else {
+			result.units = append(result.units, u)
+		}
+	}
+
+	// units whose powers cancel out are dropped
+	kept := result.units[:0]
+	for _, u := range result.units {
+		if u.power != 0 {
+			kept = append(kept, u)
+		}
+	}
+	result.units = kept
+	return result
+}
+
+// matchUnit returns the position of the unit of q with the same name or dimension as the given one, or -1.
+func (q quantity) matchUnit(name string) int {
+	target, _ := lookupUnit(name)
+	for i, u := range q.units {
+		if unit, _ := lookupUnit(u.name); u.name == name || unit.dim == target.dim {
+			return i
+		}
+	}
+	return -1
+}
+
+// displayUnits returns the units a quantity is written in. Unless they were chosen with a conversion,
+// products of units are replaced with a named unit of the same dimension, so that kg*m/s^2 is written as N.
+func (q quantity) displayUnits() []unitPower {
+	switch {
+	case q.dim == dimensionless, q.converted && len(q.units) > 0, len(q.units) == 1 && q.units[0].power == 1:
+		return q.units
+	}
+
+	for _, name := range namedUnits {
+		if unit, _ := lookupUnit(name); unit.dim == q.dim {
+			return []unitPower{{name: name, power: 1}}
+		}
+	}
+
+	if len(q.units) == 0 {
+		// the units were lost to a fractional power, as in sqrt(2 km*m)
+		return q.dim.units()
+	}
+	return q.units
+}
+
+// magnitude returns the value of q in the units it is written in.
+func (q quantity) magnitude() float64 {
+	return q.value / scale(q.displayUnits())
+}
+
+// unitText returns the units q is written in, or "" if it has none.
+func (q quantity) unitText() string {
+	return formatUnits(q.displayUnits())
+}
+
+// DimensionError describes an operation on quantities whose dimensions don't allow it,
+// such as adding a length to a time. It wraps ErrDimension so that it can be matched with errors.Is.
+type DimensionError struct {
+	// Op is the operator or function applied.
+	Op string
+	// Left and Right are the dimensions of the operands in SI base units, such as kg*m/s^2,
+	// or 1 for dimensionless ones. Right is the exponent for powers,
+	// and 1 for functions which only accept dimensionless arguments.
+	Left, Right string
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("%s for %s: %s and %s", ErrDimension, e.Op, e.Left, e.Right)
+}
+
+func (e *DimensionError) Unwrap() error {
+	return ErrDimension
+}
+
+// unitSystem evaluates expressions with physical quantities, which are float64 magnitudes with units.
+type unitSystem struct{}
+
+func (s unitSystem) literal(text string) (interface{}, error) {
+	value, err := numberValue(text)
+	if err != nil {
+		return nil, err
+	}
+	return quantity{value: value}, nil
+}
+
+func (s unitSystem) fromFloat(x float64) (interface{}, error) {
+	if math.IsNaN(x) {
+		return nil, ErrNotANumber
+	}
+	return quantity{value: x}, nil
+}
+
+func (s unitSystem) constant(name string) (interface{}, error) {
+	if value, ok := constants[name]; ok {
+		return quantity{value: value}, nil
+	}
+
+	unit, _ := lookupUnit(name)
+	return quantity{value: unit.scale, dim: unit.dim, units: []unitPower{{name: name, power: 1}}}, nil
+}
+
+func (s unitSystem) hasConstant(name string) bool {
+	_, ok := lookupUnit(name)
+	return ok
+}
+
+func (s unitSystem) owns(x interface{}) bool {
+	_, ok := x.(quantity)
+	return ok
+}
+
+func (s unitSystem) unary(op string, x interface{}) (interface{}, error) {
+	if op != subtract {
+		return nil, unsupportedOperator(op)
+	}
+
+	q := x.(quantity)
+	q.value = -q.value
+	return q, nil
+}
+
+func (s unitSystem) operate(op string, left, right interface{}) (interface{}, error) {
+	l, r := left.(quantity), right.(quantity)
+
+	switch op {
+	case add, subtract, floorDivide, modulus, convertTo:
+		if l.dim != r.dim {
+			return nil, &DimensionError{Op: op, Left: l.dim.String(), Right: r.dim.String()}
+		}
+	case power:
+		if r.dim != dimensionless {
+			return nil, &DimensionError{Op: op, Left: l.dim.String(), Right: r.dim.String()}
+		}
+	}
+
+	var result quantity
+	switch op {
+	case add, subtract, modulus:
+		// sums are written in the units of their first operand which has any
+		result = l
+		if len(l.units) == 0 {
+			result = r
+		}
+	case multiply:
+		result = l.times(r, 1)
+	case divide:
+		result = l.times(r, -1)
+	case floorDivide:
+		// the number of times r fits into l has no dimension
+	case power:
+		return s.power(l, r.value)
+	case convertTo:
+		// only the units of the right operand matter, not its magnitude
+		return quantity{value: l.value, dim: l.dim, units: r.units, converted: true}, nil
+	default:
+		return nil, unsupportedOperator(op)
+	}
+
+	value, err := operate(op, l.value, r.value)
+	if err != nil {
+		return nil, err
+	}
+	result.value = value
+	return result, nil
+}
+
+// power raises a quantity to the power of exp, which must leave its dimension whole.
+func (s unitSystem) power(base quantity, exp float64) (interface{}, error) {
+	dim, ok := base.dim.power(exp)
+	if !ok {
+		return nil, &DimensionError{Op: power, Left: base.dim.String(), Right: formatFloat(exp)}
+	}
+
+	value, err := operate(power, base.value, exp)
+	if err != nil {
+		return nil, err
+	}
+
+	result := quantity{value: value, dim: dim}
+	for _, u := range base.units {
+		p := float64(u.power) * exp
+		if p != math.Trunc(p) {
+			// units such as the km of sqrt(2 km*m) can't be kept, so the result is written in base units
+			result.units = nil
+			break
+		}
+		result.units = append(result.units, unitPower{name: u.name, power: int(p)})
+	}
+	return result, nil
+}
+
+// compare compares quantities of the same dimension by their values rounded as they are written,
+// so that 0.1 km == 100 m.
+func (s unitSystem) compare(op string, left, right interface{}) (bool, error) {
+	l, r := left.(quantity), right.(quantity)
+	if l.dim != r.dim {
+		return false, &DimensionError{Op: op, Left: l.dim.String(), Right: r.dim.String()}
+	}
+	return compared(op, compareFloats(roundSignificant(l.value), roundSignificant(r.value))), nil
+}
+
+func (s unitSystem) call(name string, fn function, args []interface{}) (interface{}, error) {
+	qs := make([]quantity, len(args))
+	for i, arg := range args {
+		qs[i] = arg.(quantity)
+	}
+	x := qs[0]
+
+	switch name {
+	case "sqrt", "cbrt":
+		if name == "sqrt" && x.value < 0 {
+			return nil, ErrDomain
+		}
+		exp := 1.0 / 2
+		if name == "cbrt" {
+			exp = 1.0 / 3
+		}
+		return s.power(quantity{value: math.Abs(x.value), dim: x.dim, units: x.units}, exp)
+	case "pow":
+		return s.operate(power, x, qs[1])
+	case "abs", "floor", "ceil", "round", "trunc":
+		// the magnitude is rounded in the units it is written in, so that round(1.6 km) is 2 km
+		magnitude, err := fn.call([]float64{x.magnitude()})
+		if err != nil {
+			return nil, err
+		}
+		x.units = x.displayUnits()
+		x.value = magnitude * scale(x.units)
+		return x, nil
+	case "min", "max", "hypot", "atan2":
+		for _, arg := range qs[1:] {
+			if arg.dim != x.dim {
+				return nil, &DimensionError{Op: name, Left: x.dim.String(), Right: arg.dim.String()}
+			}
+		}
+	default:
+		for _, arg := range qs {
+			if arg.dim != dimensionless {
+				return nil, &DimensionError{Op: name, Left: arg.dim.String(), Right: dimensionless.String()}
+			}
+		}
+	}
+
+	floats := make([]float64, len(qs))
+	for i, q := range qs {
+		floats[i] = q.value
+	}
+	result, err := fn.call(floats)
+	if err == nil {
+		err = checkFinite(result, ErrDomain, floats...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "min", "max":
+		for _, q := range qs {
+			if q.value == result {
+				return q, nil
+			}
+		}
+	case "hypot":
+		x.value = result
+		return x, nil
+	}
+	return quantity{value: result}, nil
+}
+
+func (s unitSystem) float(x interface{}) float64 {
+	return x.(quantity).magnitude()
+}
+
+// integer only returns the value of quantities without units.
+func (s unitSystem) integer(x interface{}) (*big.Int, bool) {
+	q := x.(quantity)
+	if len(q.displayUnits()) > 0 {
+		return nil, false
+	}
+	return floatInteger(roundSignificant(q.value))
+}
+
+// format writes a quantity such as 49.05 N, with its magnitude rounded to 15 significant digits
+// to hide the rounding errors of converting between units.
+func (s unitSystem) format(x interface{}) string {
+	q := x.(quantity)
+	text := formatFloat(roundSignificant(q.magnitude()))
+	if units := q.unitText(); units != "" {
+		text += whitespace + units
+	}
+	return text
+}
+
+// roundSignificant rounds a float64 to 15 significant digits, which every float64 holds exactly.
+func roundSignificant(x float64) float64 {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return x
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(x, 'g', 15, 64), 64)
+	return rounded
+}
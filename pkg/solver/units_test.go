@@ -0,0 +1,251 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_units(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"quantity", "3 km", "(3 * km)"},
+		{"quantity binds tighter than division", "10 m / 5 m", "((10 * m) / (5 * m))"},
+		{"compound unit", "9.81 m/s^2", "((9.81 * m) / (s ^ 2))"},
+		{"conversion", "60 mph to km/h", "((60 * mph) to (km / h))"},
+		{"conversion alias", "1 mi in ft", "((1 * mi) to ft)"},
+		{"conversion binds loosest", "1 km + 1 m to m", "(((1 * km) + (1 * m)) to m)"},
+		{"negated quantity", "-3 km", "((-3) * km)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression, WithUnits())
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_unitOperatorsOutsideUnitsMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantOffset int
+		wantLength int
+	}{
+		{"conversion", "1 to 2", nil, 2, 2},
+		{"conversion alias", "x in y", nil, 2, 2},
+		{"integer mode", "1 to 2", []Option{WithInteger()}, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression, tt.opts...)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !errors.Is(err, ErrUnitOperator) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, ErrUnitOperator)
+			}
+			if syntaxErr.Offset != tt.wantOffset || syntaxErr.Length != tt.wantLength {
+				t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", syntaxErr.Offset, syntaxErr.Length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestSolve_units(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantResult string
+	}{
+		{"sum in units of first operand", "3 km + 200 m", "3.2 km"},
+		{"derived unit", "5 kg * 9.81 m/s^2", "49.05 N"},
+		{"conversion", "60 mph to km/h", "96.56064 km/h"},
+		{"conversion alias", "1 mi in ft", "5280 ft"},
+		{"prefixed conversion", "100 W * 2 h in kWh", "0.2 kWh"},
+		{"units of same dimension combine", "2 km * 3 m", "0.006 km^2"},
+		{"units cancel out", "10 m / 5 m", "2"},
+		{"ratio of different units", "1 km / 1 m", "1000"},
+		{"compound unit", "1 km / 1 h", "1 km/h"},
+		{"named unit of base units", "1 J / 1 N", "1 m"},
+		{"reciprocal", "1 / 2 s", "0.5 Hz"},
+		{"electrical units", "20 mA * 5 kohm", "100 V"},
+		{"micro prefix", "25 µm + 1 mm", "1025 µm"},
+		{"square root", "sqrt(16 m^2)", "4 m"},
+		{"cube root", "cbrt(8 m^3)", "2 m"},
+		{"rounding in units", "round(1.6 km)", "2 km"},
+		{"floor division", "3 km // 2000 m", "1"},
+		{"modulus", "7 km % 2 km", "1 km"},
+		{"maximum", "max(1 km, 500 m)", "1 km"},
+		{"hypotenuse", "hypot(3 m, 4 m)", "5 m"},
+		{"angle", "sin(90 deg)", "1"},
+		{"angle conversion", "pi/2 to deg", "90 deg"},
+		{"negation", "-3 km", "-3 km"},
+		{"variable shadows unit", "m = 5; m * 2", "10"},
+		{"program", "d = 100 m; t = 9.58 s; d / t to km/h", "37.5782881002088 km/h"},
+		{"user-defined function", "speed(d, t) = d / t; speed(42.195 km, 2 h)", "21.0975 km/h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, WithUnits())
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_dimensionErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    DimensionError
+	}{
+		{"sum", "3 m + 2 s", DimensionError{Op: add, Left: "m", Right: "s"}},
+		{"difference", "1 N - 1 J", DimensionError{Op: subtract, Left: "kg*m/s^2", Right: "kg*m^2/s^2"}},
+		{"conversion", "2 km to kg", DimensionError{Op: convertTo, Left: "m", Right: "kg"}},
+		{"conversion alias", "1 h in m/s", DimensionError{Op: convertTo, Left: "s", Right: "m/s"}},
+		{"dimensionless operand", "1 km + 1", DimensionError{Op: add, Left: "m", Right: "1"}},
+		{"power with units", "2^(1 m)", DimensionError{Op: power, Left: "1", Right: "m"}},
+		{"fractional dimension", "(2 m)^0.5", DimensionError{Op: power, Left: "m", Right: "0.5"}},
+		{"function of dimensionless arguments", "sin(2 m)", DimensionError{Op: "sin", Left: "m", Right: "1"}},
+		{"function of same dimensions", "min(1 m, 1 s)", DimensionError{Op: "min", Left: "m", Right: "s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, WithUnits())
+
+			var dimensionErr *DimensionError
+			if !errors.As(err, &dimensionErr) || !errors.Is(err, ErrDimension) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, ErrDimension)
+			}
+			if *dimensionErr != tt.wantErr {
+				t.Errorf("\nGot:\t%+v\nWant:\t%+v", *dimensionErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEval_unitErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    error
+	}{
+		{"division by zero", "1 m / (0 s)", []Option{WithUnits()}, ErrDivisionByZero},
+		{"square root of negative", "sqrt(-4 m^2)", []Option{WithUnits()}, ErrDomain},
+		{"undefined name", "3 furlong", []Option{WithUnits()}, ErrUndefinedName},
+		{"unprefixable unit", "1 kmin", []Option{WithUnits()}, ErrUndefinedName},
+		{"unit outside units mode", "3 km", nil, ErrUndefinedName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResult_Unit(t *testing.T) {
+	tests := []struct {
+		expression string
+		opts       []Option
+		wantFloat  float64
+		wantUnit   string
+	}{
+		{"3 km + 200 m", []Option{WithUnits()}, 3.2, "km"},
+		{"2 kg * 3 m/s^2", []Option{WithUnits()}, 6, "N"},
+		{"10 m / 4 m", []Option{WithUnits()}, 2.5, ""},
+		{"2.5", nil, 2.5, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Eval(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.Float() != tt.wantFloat || result.Unit() != tt.wantUnit {
+				t.Errorf("\nGot:\t%v, %q\nWant:\t%v, %q", result.Float(), result.Unit(), tt.wantFloat, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestEvalWithEnv_units(t *testing.T) {
+	env := NewEnv(map[string]float64{"laps": 4})
+
+	result, err := EvalWithEnv("track = 400 m; pace(t) = laps * track / t; lap(n) = n * 400 m; convert(x, u) = x to u", env, WithUnits())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	// quantities keep their units for later evaluations in units mode
+	result, err = EvalWithEnv("pace(4 min) to km/h", env, WithUnits())
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+	if result.String() != "24 km/h" {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", result, "24 km/h")
+	}
+
+	// functions using units can't be called outside of units mode
+	_, err = EvalWithEnv("lap(2)", env)
+	if !errors.Is(err, ErrUndefinedName) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrUndefinedName)
+	}
+	_, err = EvalWithEnv("convert(10, 1)", env)
+	if !errors.Is(err, ErrUnitOperator) {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", err, ErrUnitOperator)
+	}
+}
+
+func Test_lookupUnit(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantScale float64
+		wantOk    bool
+	}{
+		{"m", 1, true},
+		{"km", 1000, true},
+		{"kg", 1, true},
+		{"mg", 1e-6, true},
+		{"dam", 10, true},
+		{"µs", 1e-6, true},
+		{"min", 60, true},
+		{"nmi", 1852, true},
+		{"kmi", 0, false},
+		{"x", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, ok := lookupUnit(tt.name)
+			if ok != tt.wantOk || unit.scale != tt.wantScale {
+				t.Errorf("\nGot:\t%v, %v\nWant:\t%v, %v", unit.scale, ok, tt.wantScale, tt.wantOk)
+			}
+		})
+	}
+}
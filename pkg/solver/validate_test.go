@@ -1,6 +1,9 @@
 package solver
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func Test_validate(t *testing.T) {
 	tests := []struct {
@@ -9,7 +12,22 @@ func Test_validate(t *testing.T) {
 		wantErr    error
 	}{
 		{"valid expression", "2 + 5 + 6", nil},
-		{"illegal character", "3 + b - 2", ErrIllegalCharacter},
+		{"illegal character", "3 + # - 2", ErrIllegalCharacter},
+		{"function call", "3 + sqrt(4) - max(1, 2, 3)", nil},
+		{"function call without arguments", "f()", nil},
+		{"unknown function call", "3 + unknown(4)", nil},
+		{"constant", "3 + pi", nil},
+		{"implicit multiplication of constant", "2pi + 3(e+1)", nil},
+		{"consecutive names", "pi e", ErrMalformedExp},
+		{"number after name", "pi 2", ErrMalformedExp},
+		{"number after group", "(2)3", ErrMalformedExp},
+		{"argument list without function", "(1, 2)", ErrMalformedExp},
+		{"trailing comma", "max(1, )", ErrMalformedExp},
+		{"comma outside of argument list", "1, 2", ErrMalformedExp},
+		{"function call after operand", "2 sqrt(4)", nil},
+		{"unclosed parenthesis", "(2 + (3)", ErrMalformedExp},
+		{"unopened parenthesis", "2 + 3)", ErrMalformedExp},
+		{"unclosed argument list", "max(1, 2", ErrMalformedExp},
 		{"legal start (digit)", "2/3", nil},
 		{"legal start (parenthesis)", "(2/3)", nil},
 		{"legal start (subtraction)", "-2/3", nil},
@@ -27,12 +45,24 @@ func Test_validate(t *testing.T) {
 		{"illegal end (division)", "2/3/", ErrIllegalEnd},
 		{"illegal end (decimal)", "2/3.", ErrIllegalEnd},
 		{"empty parenthesis", "2/3 + ()", ErrEmptyParentheses},
+		{"nested parentheses", "2*((3+4)*(5-1))", nil},
+		{"deeply nested parentheses", "2(3(4(5(6+1))))", nil},
 		{"legal consecutive operator (double minus)", "4--2", nil},
 		{"illegal consecutive operator (triple minus)", "4---2", ErrIllegalConsecutiveOperator},
 		{"illegal consecutive operator (addition)", "4++2", ErrIllegalConsecutiveOperator},
-		{"illegal consecutive operator (multiplication)", "4**2", ErrIllegalConsecutiveOperator},
-		{"illegal consecutive operator (division)", "4//2", ErrIllegalConsecutiveOperator},
-		{"illegal consecutive operator (decimal)", "4..2", ErrIllegalConsecutiveOperator},
+		{"legal consecutive operator (exponentiation)", "4**2", nil},
+		{"legal consecutive operator (floor division)", "4//2", nil},
+		{"illegal consecutive operator (multiplication)", "4***2", ErrIllegalConsecutiveOperator},
+		{"illegal consecutive operator (division)", "4///2", ErrIllegalConsecutiveOperator},
+		{"illegal consecutive operator (power)", "4^^2", ErrIllegalConsecutiveOperator},
+		{"illegal consecutive operator (modulus)", "4*%2", ErrIllegalConsecutiveOperator},
+		{"legal percentage", "4%*2", nil},
+		{"legal modulus", "7 % 3", nil},
+		{"legal exponentiation", "2^-3", nil},
+		{"illegal start (modulus)", "%2", ErrIllegalStart},
+		{"illegal end (power)", "2^", ErrIllegalEnd},
+		{"illegal consecutive operator (decimal)", "4...2", ErrIllegalConsecutiveOperator},
+		{"legal range", "4..2", nil},
 		{"illegal consecutive operator (mixed)", "4/+2", ErrIllegalConsecutiveOperator},
 		{"illegal consecutive operator (mixed)", "4*/2", ErrIllegalConsecutiveOperator},
 		{"illegal consecutive operator (multiplication then decimal)", "4*.2", ErrIllegalConsecutiveOperator},
@@ -48,43 +78,32 @@ func Test_validate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			_, err := Validate(tt.expression)
 
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_maxDepth(t *testing.T) {
+func TestValidate_maxDepth(t *testing.T) {
 	tests := []struct {
 		name       string
 		expression string
-		wantDepth  int
+		maxDepth   int
 		wantErr    error
 	}{
-		{"valid expression", "", 0, nil},
-		{"valid expression", "()", 1, nil},
-		{"valid expression", "()()", 1, nil},
-		{"valid expression", "(())", 2, nil},
-		{"valid expression", "()((()))()(())", 3, nil},
-		{"malformed expression", "(", -1, ErrMalformedExp},
-		{"malformed expression", ")", -1, ErrMalformedExp},
-		{"malformed expression", "(()", -1, ErrMalformedExp},
-		{"malformed expression", "())", -1, ErrMalformedExp},
-		{"valid expression", "()((()))()((())", -1, ErrMalformedExp},
+		{"within limit", "((2+3))", 2, nil},
+		{"exceeds limit", "(((2+3)))", 2, ErrDepthExceeded},
+		{"unnested within limit of 1", "(2+3)(4)", 1, nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			depth, err := maxDepth(tt.expression)
+			_, err := Validate(tt.expression, WithMaxDepth(tt.maxDepth))
 
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
 			}
-
-			if depth != tt.wantDepth {
-				t.Errorf("\nGot:\t%d\nWant:\t%d", depth, tt.wantDepth)
-			}
 		})
 	}
 }
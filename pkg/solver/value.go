@@ -0,0 +1,258 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of a Value.
+type Type int
+
+// types of values, the zero Type being none of them
+const (
+	NumberType Type = iota + 1
+	BoolType
+	StringType
+	ListType
+)
+
+// typeNames are the names of the types as written in type errors.
+var typeNames = [...]string{
+	NumberType: "number",
+	BoolType:   "bool",
+	StringType: "string",
+	ListType:   "list",
+}
+
+func (t Type) String() string {
+	if t < NumberType || t > ListType {
+		return ""
+	}
+	return typeNames[t]
+}
+
+// Value is the value of an expression or of one of its parts, which is a Number, Bool, String or List.
+type Value interface {
+	// Type returns the type of the value.
+	Type() Type
+	// String returns the value as it is written in expressions, such as "km" for a string.
+	String() string
+}
+
+// Number is a number. Numbers of evaluations in other number systems, such as with WithRational,
+// are approximated by their float64 values.
+type Number float64
+
+// Bool is a boolean, such as the result of a comparison.
+type Bool bool
+
+// String is a text, written between double quotes in expressions, as in "km".
+// Quotes and backslashes inside it are escaped with a backslash, as in Go.
+type String string
+
+// List is a sequence of values, which may be of different types.
+type List []Value
+
+func (n Number) Type() Type { return NumberType }
+func (b Bool) Type() Type   { return BoolType }
+func (s String) Type() Type { return StringType }
+func (l List) Type() Type   { return ListType }
+
+func (n Number) String() string { return formatFloat(float64(n)) }
+func (b Bool) String() string   { return strconv.FormatBool(bool(b)) }
+func (s String) String() string { return strconv.Quote(string(s)) }
+
+func (l List) String() string {
+	elements := make([]string, len(l))
+	for i, element := range l {
+		elements[i] = element.String()
+	}
+	return "[" + strings.Join(elements, comma+whitespace) + "]"
+}
+
+// TypeError describes an operator or function applied to a value of the wrong type,
+// such as a boolean added to a number. It wraps ErrType so that it can be matched with errors.Is.
+type TypeError struct {
+	// Op is the operator or function applied, which is "?" for the condition of a conditional.
+	Op string
+	// Left and Right are the types of the operands. Right is zero for prefix operators,
+	// functions and conditions, and for the left operand of && and ||,
+	// whose right operand isn't evaluated once the left one is of the wrong type.
+	Left, Right Type
+}
+
+// typeVerbs describe what the operators which have a verb of their own do, as written in type errors.
+var typeVerbs = map[string]string{
+	add:              "add",
+	subtract:         "subtract",
+	multiply:         "multiply",
+	multiplyElements: "multiply",
+	divide:           "divide",
+	floorDivide:      "divide",
+	less:             "compare",
+	lessOrEqual:      "compare",
+	equal:            "compare",
+	notEqual:         "compare",
+	greaterOrEqual:   "compare",
+	greater:          "compare",
+	indexing:         "index",
+}
+
+func (e *TypeError) Error() string {
+	types := e.Left.String()
+	if e.Right != 0 {
+		types += " and " + e.Right.String()
+	}
+
+	_, isFunction := functions[e.Op]
+	switch verb, ok := typeVerbs[e.Op]; {
+	case e.Op == subtract && e.Right == 0:
+		return fmt.Sprintf("cannot negate %s", types)
+	case e.Op == indexing && e.Right != 0:
+		return fmt.Sprintf("cannot index %s with %s", e.Left, e.Right)
+	case ok:
+		return fmt.Sprintf("cannot %s %s", verb, types)
+	case e.Op == question:
+		return fmt.Sprintf("cannot use %s as a condition", types)
+	case isFunction:
+		return fmt.Sprintf("cannot call %s with %s", e.Op, types)
+	}
+	return fmt.Sprintf("cannot apply %s to %s", e.Op, types)
+}
+
+func (e *TypeError) Unwrap() error {
+	return ErrType
+}
+
+// value is a Value computed by the virtual machine.
+// Numbers are held without an interface so that arithmetic doesn't allocate.
+type value struct {
+	num float64
+	// ref holds values other than numbers, and is nil for numbers.
+	ref Value
+}
+
+// number returns the value of a number.
+func number(x float64) value {
+	return value{num: x}
+}
+
+// boolean returns the value of a boolean.
+func boolean(b bool) value {
+	return value{ref: Bool(b)}
+}
+
+// fromValue returns the value holding v. Numbers of number systems are approximated.
+func fromValue(v Value) value {
+	if v.Type() == NumberType {
+		return number(approximate(v))
+	}
+	return value{ref: v}
+}
+
+// public returns v as a Value.
+func (v value) public() Value {
+	if v.ref == nil {
+		return Number(v.num)
+	}
+	return v.ref
+}
+
+// publicValues returns values as Values.
+func publicValues(values []value) []Value {
+	public := make([]Value, len(values))
+	for i, v := range values {
+		public[i] = v.public()
+	}
+	return public
+}
+
+// typ returns the type of v.
+func (v value) typ() Type {
+	if v.ref == nil {
+		return NumberType
+	}
+	return v.ref.Type()
+}
+
+// truth returns the boolean held by v, which is false if v isn't a boolean.
+func (v value) truth() bool {
+	b, _ := v.ref.(Bool)
+	return bool(b)
+}
+
+// approximate returns the float64 a Value is stored in an Env as, which is 1 for true and 0 for false,
+// and NaN for strings and lists.
+func approximate(v Value) float64 {
+	switch v := v.(type) {
+	case Number:
+		return float64(v)
+	case systemNumber:
+		return v.system.float(v.x)
+	case Bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	return math.NaN()
+}
+
+// operateOthers applies an infix operator other than && and || to two values which aren't both numbers,
+// with the arithmetic of s for the numbers in lists. Lists are combined as operateLists does,
+// values of other different types can't be combined, values of the same type can be compared for equality,
+// and strings can also be ordered and joined with +.
+func operateOthers(s scalars, op string, left, right Value) (Value, error) {
+	if result, ok, err := operateLists(s, op, left, right); ok {
+		return result, err
+	}
+
+	switch {
+	case left.Type() != right.Type():
+		return nil, &TypeError{Op: op, Left: left.Type(), Right: right.Type()}
+	case op == equal || op == notEqual:
+		return Bool(equalValues(left, right) == (op == equal)), nil
+	}
+
+	if l, ok := left.(String); ok {
+		r := right.(String)
+		switch {
+		case op == add:
+			return l + r, nil
+		case isComparison(op):
+			return Bool(compared(op, strings.Compare(string(l), string(r)))), nil
+		}
+	}
+	return nil, &TypeError{Op: op, Left: left.Type(), Right: right.Type()}
+}
+
+// equalValues reports whether two values of the same type are equal. Lists are equal if their elements are,
+// and numbers of the same number system are compared exactly.
+func equalValues(a, b Value) bool {
+	switch l := a.(type) {
+	case List:
+		r := b.(List)
+		if len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if l[i].Type() != r[i].Type() || !equalValues(l[i], r[i]) {
+				return false
+			}
+		}
+		return true
+
+	case systemNumber:
+		if r, ok := b.(systemNumber); ok && l.system.owns(r.x) {
+			same, err := l.system.compare(equal, l.x, r.x)
+			return same && err == nil
+		}
+	}
+
+	if a.Type() == NumberType {
+		return approximate(a) == approximate(b)
+	}
+	return a == b
+}
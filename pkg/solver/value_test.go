@@ -0,0 +1,276 @@
+package solver
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParse_strings(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantTree   string
+	}{
+		{"string", `"kg"`, `"kg"`},
+		{"concatenation", `"a" + b`, `("a" + b)`},
+		{"escapes", `"say \"hi\"\n"`, `"say \"hi\"\n"`},
+		{"comparison", `unit == "kg" ? 1 : 2`, `((unit == "kg") ? 1 : 2)`},
+		{"argument", `f("x", 1)`, `f("x", 1)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if tree.String() != tt.wantTree {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", tree, tt.wantTree)
+			}
+		})
+	}
+}
+
+func TestParse_stringErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+		wantOffset int
+		wantLength int
+	}{
+		{"unterminated", `1 + "kg`, ErrUnterminatedString, 4, 3},
+		{"unknown escape", `"\q"`, ErrMalformedExp, 0, 4},
+		{"consecutive strings", `"a" "b"`, ErrMalformedExp, 4, 3},
+		{"implicit multiplication", `2"a"`, ErrMalformedExp, 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+			}
+			if syntaxErr.Offset != tt.wantOffset || syntaxErr.Length != tt.wantLength {
+				t.Errorf("\nGot:\t%d, %d\nWant:\t%d, %d", syntaxErr.Offset, syntaxErr.Length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestSolve_strings(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantResult string
+	}{
+		{"string", `"kg"`, nil, `"kg"`},
+		{"concatenation", `"k" + "g"`, nil, `"kg"`},
+		{"escapes", `"a\tb" + "\""`, nil, `"a\tb\""`},
+		{"equality", `"kg" == "k" + "g"`, nil, "true"},
+		{"inequality", `"kg" != "KG"`, nil, "true"},
+		{"ordering", `"apple" < "banana"`, nil, "true"},
+		{"conditional", `unit = "mi"; unit == "km" ? 1 : 1.609`, nil, "1.609"},
+		{"string branches", `x = 3; x > 2 ? "big" : "small"`, nil, `"big"`},
+		{"user-defined function", `greet(name) = "hello " + name; greet("ada")`, nil, `"hello ada"`},
+		{"precision", `x = 1/3; x > 0.3 ? "over" : "under"`, []Option{WithPrecision(34)}, `"over"`},
+		{"rational", `"a" + "b" == "ab"`, []Option{WithRational(Fraction, RejectInexact)}, "true"},
+		{"units", `d = 1 km; d > 900 m ? "far" : "near"`, []Option{WithUnits()}, `"far"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Solve(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEval_stringTypeErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		opts       []Option
+		wantErr    TypeError
+		wantMsg    string
+	}{
+		{"sum of string and number", `"a" + 1`, nil, TypeError{Op: add, Left: StringType, Right: NumberType},
+			`cannot add string and number: "+" at column 5`},
+		{"sum of bool and number", `x = 1; true + x`, nil, TypeError{Op: add, Left: BoolType, Right: NumberType},
+			`cannot add bool and number: "+" in statement 2 at column 13`},
+		{"product of strings", `"a" * "b"`, nil, TypeError{Op: multiply, Left: StringType, Right: StringType},
+			`cannot multiply string and string: "*" at column 5`},
+		{"equality of string and number", `"1" == 1`, nil, TypeError{Op: equal, Left: StringType, Right: NumberType},
+			`cannot compare string and number: "==" at column 5`},
+		{"string as percent of number", `"a" as % of 2`, nil, TypeError{Op: asPercentOf, Left: StringType, Right: NumberType},
+			`cannot apply as % of to string and number: "as % of" at column 5`},
+		{"negation of string", `-"a"`, nil, TypeError{Op: subtract, Left: StringType},
+			`cannot negate string: "-\"a\"" at column 1`},
+		{"not of string", `!"a"`, nil, TypeError{Op: not, Left: StringType},
+			`cannot apply ! to string: "!\"a\"" at column 1`},
+		{"percentage of string", `"a"%`, nil, TypeError{Op: percent, Left: StringType},
+			`cannot apply % to string: "\"a\"%" at column 1`},
+		{"string as condition", `"yes" ? 1 : 2`, nil, TypeError{Op: question, Left: StringType},
+			`cannot use string as a condition: "\"yes\"" at column 1`},
+		{"function of string", `sqrt("4")`, nil, TypeError{Op: "sqrt", Left: StringType},
+			`cannot call sqrt with string: "sqrt(\"4\")" at column 1`},
+		{"string in and", `true && "a"`, nil, TypeError{Op: and, Left: BoolType, Right: StringType},
+			`cannot apply && to bool and string: "&&" at column 6`},
+		{"precision", `"a" + 1`, []Option{WithPrecision(34)}, TypeError{Op: add, Left: StringType, Right: NumberType},
+			`cannot add string and number: "+" at column 5`},
+		{"integer", `~"a"`, []Option{WithInteger()}, TypeError{Op: bitNot, Left: StringType},
+			`cannot apply ~ to string: "~\"a\"" at column 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, tt.opts...)
+
+			var typeErr *TypeError
+			if !errors.As(err, &typeErr) || !errors.Is(err, ErrType) {
+				t.Fatalf("\nGot:\t%v\nWant:\t%v", err, ErrType)
+			}
+			if *typeErr != tt.wantErr {
+				t.Errorf("\nGot:\t%+v\nWant:\t%+v", *typeErr, tt.wantErr)
+			}
+			if err.Error() != tt.wantMsg {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestResult_Value(t *testing.T) {
+	tests := []struct {
+		expression string
+		opts       []Option
+		wantType   Type
+		wantValue  Value
+		wantFloat  float64
+	}{
+		{"2.5", nil, NumberType, Number(2.5), 2.5},
+		{"1 < 2", nil, BoolType, Bool(true), 1},
+		{`"k" + "g"`, nil, StringType, String("kg"), math.NaN()},
+		{"1/4", []Option{WithRational(Fraction, RejectInexact)}, NumberType, Number(0.25), 0.25},
+		{"1/4 > 1/5", []Option{WithRational(Fraction, RejectInexact)}, BoolType, Bool(true), 1},
+		{`"kg"`, []Option{WithUnits()}, StringType, String("kg"), math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Eval(tt.expression, tt.opts...)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if result.Type() != tt.wantType || !reflect.DeepEqual(result.Value(), tt.wantValue) {
+				t.Errorf("\nGot:\t%v, %#v\nWant:\t%v, %#v", result.Type(), result.Value(), tt.wantType, tt.wantValue)
+			}
+
+			got := result.Float()
+			if got != tt.wantFloat && !(math.IsNaN(got) && math.IsNaN(tt.wantFloat)) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", got, tt.wantFloat)
+			}
+		})
+	}
+}
+
+func TestEnv_SetValue(t *testing.T) {
+	env := &Env{}
+	env.SetValue("rate", Number(1.5))
+	env.SetValue("member", Bool(true))
+	env.SetValue("country", String("NL"))
+	env.SetValue("tags", List{String("a"), Number(1)})
+
+	tests := []struct {
+		name      string
+		wantValue Value
+		wantFloat float64
+	}{
+		{"rate", Number(1.5), 1.5},
+		{"member", Bool(true), 1},
+		{"country", String("NL"), math.NaN()},
+		{"tags", List{String("a"), Number(1)}, math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := env.Value(tt.name)
+			if !ok || !reflect.DeepEqual(value, tt.wantValue) {
+				t.Errorf("\nGot:\t%#v, %v\nWant:\t%#v, %v", value, ok, tt.wantValue, true)
+			}
+
+			got, _ := env.Get(tt.name)
+			if got != tt.wantFloat && !(math.IsNaN(got) && math.IsNaN(tt.wantFloat)) {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", got, tt.wantFloat)
+			}
+		})
+	}
+
+	// values keep their type in every mode, and assignments store them back
+	for _, opts := range [][]Option{nil, {WithPrecision(34)}} {
+		result, err := EvalWithEnv(`label = country + "-" + (member ? "m" : "x"); label`, env, opts...)
+		if err != nil {
+			t.Fatalf("\nUnexpected error:\t%v", err)
+		}
+		if result.String() != `"NL-m"` {
+			t.Errorf("\nGot:\t%s\nWant:\t%s", result, `"NL-m"`)
+		}
+		if value, _ := env.Value("label"); value != String("NL-m") {
+			t.Errorf("\nGot:\t%#v\nWant:\t%#v", value, String("NL-m"))
+		}
+	}
+
+	if _, ok := (*Env)(nil).Value("rate"); ok {
+		t.Errorf("\nGot:\t%v\nWant:\t%v", ok, false)
+	}
+}
+
+func TestList_String(t *testing.T) {
+	list := List{Number(1.5), Bool(false), String("a\"b"), List{}, List{Number(-2)}}
+	want := `[1.5, false, "a\"b", [], [-2]]`
+
+	if got := list.String(); got != want {
+		t.Errorf("\nGot:\t%s\nWant:\t%s", got, want)
+	}
+}
+
+func Test_operateOthers(t *testing.T) {
+	tests := []struct {
+		name        string
+		op          string
+		left, right Value
+		want        Value
+	}{
+		{"equal lists", equal, List{Number(1), String("a")}, List{Number(1), String("a")}, Bool(true)},
+		{"lists of different lengths", equal, List{Number(1)}, List{Number(1), Number(1)}, Bool(false)},
+		{"lists of different element types", notEqual, List{Number(1)}, List{Bool(true)}, Bool(true)},
+		{"nested lists", equal, List{List{Bool(true)}}, List{List{Bool(true)}}, Bool(true)},
+		{"string ordering", greaterOrEqual, String("b"), String("ab"), Bool(true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := operateOthers(floatScalars{}, tt.op, tt.left, tt.right)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("\nGot:\t%v\nWant:\t%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package solver
+
+import "sync"
+
+// machine is a stack-based virtual machine which runs compiled chunks.
+type machine struct {
+	stack []value
+	// floats holds the arguments of library function calls, which only accept numbers.
+	floats []float64
+	// globals is the scope names are resolved from and assigned to. It may be nil until something is assigned.
+	globals *Env
+	cfg     *config
+}
+
+// machines holds idle machines so that their stacks are reused between evaluations.
+var machines = sync.Pool{
+	New: func() interface{} { return &machine{} },
+}
+
+// execute runs a compiled program, resolving names from env and storing assignments and function definitions in it.
+func execute(c *chunk, env *Env, cfg *config) (value, error) {
+	if undefined := c.undefined(env); len(undefined) > 0 {
+		return value{}, newUndefinedEvalError(c.expr, undefined)
+	}
+
+	m := machines.Get().(*machine)
+	m.globals, m.cfg = env, cfg
+
+	v, err := m.run(c, 0, 0)
+
+	// the pool must not keep the environment alive
+	m.stack, m.globals, m.cfg = m.stack[:0], nil, nil
+	machines.Put(m)
+
+	return v, err
+}
+
+// run executes the instructions of a chunk and returns the value left on top of the stack.
+// base is the position on the stack of the arguments of the function being called,
+// and depth the number of user-defined function calls in progress.
+func (m *machine) run(c *chunk, base, depth int) (value, error) {
+	for ip := 0; ip < len(c.code); ip++ {
+		ins := &c.code[ip]
+		top := len(m.stack) - 1
+
+		switch ins.op {
+		case opConst:
+			m.stack = append(m.stack, number(c.constants[ins.arg]))
+
+		case opBool:
+			m.stack = append(m.stack, boolean(ins.arg != 0))
+
+		case opValue:
+			m.stack = append(m.stack, value{ref: c.values[ins.arg]})
+
+		case opList:
+			elements := len(m.stack) - ins.arg
+			list := newList(m.stack[elements:])
+			m.stack = append(m.stack[:elements], list)
+
+		case opIndex:
+			element, err := indexValue(m.stack[top-1], m.stack[top])
+			if err != nil {
+				return value{}, newEvalError(c.expr, err, ins.node)
+			}
+			m.stack[top-1] = element
+			m.stack = m.stack[:top]
+
+		case opLoad:
+			v, ok := m.globals.lookupValue(c.names[ins.arg])
+			if !ok {
+				// a name used by a function body was removed after the function was defined
+				return value{}, newUndefinedEvalError(c.expr, []*IdentNode{ins.node.(*IdentNode)})
+			}
+			m.stack = append(m.stack, v)
+
+		case opParam:
+			m.stack = append(m.stack, m.stack[base+ins.arg])
+
+		case opNegate, opNot:
+			result, err := negate(operatorOf(ins.node), m.stack[top])
+			if err != nil {
+				return value{}, newEvalError(c.expr, err, ins.node)
+			}
+			m.stack[top] = result
+
+		case opFactorial:
+			result, err := factorialOf(m.stack[top])
+			if err != nil {
+				return value{}, newEvalError(c.expr, err, ins.node)
+			}
+			m.stack[top] = result
+
+		case opPercent:
+			if m.stack[top].ref != nil {
+				return value{}, newEvalError(c.expr, &TypeError{Op: percent, Left: m.stack[top].typ()}, ins.node)
+			}
+			m.stack[top].num /= 100
+
+		case opAdd, opSubtract, opMultiply, opMultiplyElements, opDivide, opFloorDivide, opModulus, opPower,
+			opAddPercent, opSubtractPercent, opMultiplyPercent, opDividePercent, opPercentOf, opAsPercentOf,
+			opLess, opLessOrEqual, opEqual, opNotEqual, opGreaterOrEqual, opGreater, opRange:
+			result, err := operateValues(binaryOperators[ins.op], m.stack[top-1], m.stack[top])
+			if err != nil {
+				return value{}, newOperatorEvalError(c.expr, err, ins.node)
+			}
+			m.stack[top-1] = result
+			m.stack = m.stack[:top]
+
+		case opJump:
+			ip = ins.arg - 1
+
+		case opJumpUnless:
+			if err := checkBoolean(question, m.stack[top], 0); err != nil {
+				return value{}, newEvalError(c.expr, err, ins.node)
+			}
+			if !m.stack[top].truth() {
+				ip = ins.arg - 1
+			}
+			m.stack = m.stack[:top]
+
+		case opJumpIfFalse, opJumpIfTrue:
+			if err := checkBoolean(operatorOf(ins.node), m.stack[top], 0); err != nil {
+				return value{}, newOperatorEvalError(c.expr, err, ins.node)
+			}
+			if m.stack[top].truth() == (ins.op == opJumpIfTrue) {
+				ip = ins.arg - 1
+			} else {
+				m.stack = m.stack[:top]
+			}
+
+		case opCheckBool:
+			if err := checkBoolean(operatorOf(ins.node), m.stack[top], BoolType); err != nil {
+				return value{}, newOperatorEvalError(c.expr, err, ins.node)
+			}
+
+		case opCall:
+			args := len(m.stack) - c.calls[ins.arg].args
+			result, err := m.call(c, ins.node.(*CallNode), args, depth)
+			if err != nil {
+				return value{}, err
+			}
+			m.stack = append(m.stack[:args], result)
+
+		case opAssign:
+			m.scope().setValue(c.names[ins.arg], m.stack[top])
+
+		case opDefine:
+			fn := c.funcs[ins.arg]
+			m.scope().store(fn.name, fn)
+			// definitions have no value of their own
+			m.stack = append(m.stack, number(0))
+
+		case opPop:
+			m.stack = m.stack[:top]
+
+		case opModeOnly:
+			return value{}, newEvalError(c.expr, unsupportedOperator(operatorOf(ins.node)), ins.node)
+		}
+	}
+
+	v := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return v, nil
+}
+
+// call computes the value of a call whose arguments are on the stack from position args onwards.
+func (m *machine) call(c *chunk, node *CallNode, args, depth int) (value, error) {
+	fn, userFn, err := resolveFunction(c.expr, node, m.globals)
+	if err != nil {
+		return value{}, err
+	}
+
+	if userFn == nil && fn.matrix != nil {
+		result, err := callMatrixFunction(c.expr, node, fn, floatScalars{}, publicValues(m.stack[args:]))
+		if err != nil {
+			return value{}, err
+		}
+		return fromValue(result), nil
+	}
+
+	if userFn == nil {
+		m.floats, err = appendArguments(m.floats[:0], node.Name, fn, m.stack[args:])
+		if err != nil {
+			return value{}, newEvalError(c.expr, err, node)
+		}
+
+		result, err := callLibraryFunction(c.expr, node, fn, m.floats)
+		return number(result), err
+	}
+
+	if depth >= m.cfg.maxCallDepth {
+		return value{}, newEvalError(c.expr, ErrCallDepthExceeded, node)
+	}
+
+	v, err := m.run(userFn.code, args, depth+1)
+	if err != nil {
+		return value{}, newCallEvalError(c.expr, err.(*EvalError), node)
+	}
+	return v, nil
+}
+
+// scope returns the global scope, creating it if the program was evaluated without one.
+func (m *machine) scope() *Env {
+	if m.globals == nil {
+		m.globals = &Env{}
+	}
+	return m.globals
+}
+
+// operatorOf returns the operator of a unary or binary node.
+func operatorOf(node Node) string {
+	switch node := node.(type) {
+	case *UnaryNode:
+		return node.Op
+	case *BinaryNode:
+		return node.Op
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProgram_Disassemble(t *testing.T) {
+	program, err := Compile("x = 2; f(a) = a * x; -f(3) + sqrt(4)")
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	want := `0000  const    2
+0001  assign   x
+0002  pop
+0003  define   f
+0004  pop
+0005  const    3
+0006  call     f 1
+0007  neg
+0008  const    4
+0009  call     sqrt 1
+0010  add
+
+f(a):
+0000  param    a
+0001  load     x
+0002  mul
+`
+
+	if got := program.Disassemble(); got != want {
+		t.Errorf("\nGot:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestProgram_Disassemble_system(t *testing.T) {
+	program, err := Compile("x = 2; x / 3", WithRational(Fraction, RejectInexact))
+	if err != nil {
+		t.Fatalf("\nUnexpected error:\t%v", err)
+	}
+
+	if got := program.Disassemble(); got != noBytecode {
+		t.Errorf("\nGot:\n%s\nWant:\n%s", got, noBytecode)
+	}
+}
+
+// TestProgram_Eval_programs checks the values and errors of programs run by the virtual machine.
+func TestProgram_Eval_programs(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantResult string
+		wantErr    string
+	}{
+		{"2(3.54 * 2.00 -1000 /200) (20 + 30 * 2)", "332.8", ""},
+		{"-2^2 + 7 % 3 // 2 - 1/4", "-4.25", ""},
+		{"max(1, min(2, 3))^2 + log(8, 2)", "7", ""},
+		{"x = 3; y = x^2 + 1; y / 2", "5", ""},
+		{"f(x, y) = x^2 + y; g(x) = f(x, 1) / 2; g(3) + f(1, 1)", "7", ""},
+		{"x = 100; h(x) = x + 1; h(1) + x", "102", ""},
+		{"sqrt(x) = -x; sqrt(9)", "-9", ""},
+		{"x = 2; y = x / (x - 2); y", "", `division by zero: "x / (x - 2)" in statement 2 at column 12`},
+		{"f(x) = 1 / x; g(x) = f(x - 1); 2 + g(1)", "", `division by zero: "1 / x" in f(x - 1) in g(1) in statement 3 at column 36`},
+		{"f(n) = n * f(n - 1); f(3)", "", `maximum function call depth exceeded: "f(3)" in statement 2 at column 22`},
+		{"f(x) = x; f(1, 2)", "", `wrong number of arguments: f expects 1 argument: "f(1, 2)" in statement 2 at column 11`},
+		{"undefined(1)", "", `unknown function: "undefined(1)" at column 1`},
+		{"y = x + 1; x = 2", "", "undefined name: x in statement 1 at column 5"},
+		{"sqrt(-1)", "", `argument is outside the function's domain: "sqrt(-1)" at column 1`},
+		{"2^1024", "", `number is too large to represent: "2^1024" at column 1`},
+		{"inf - inf", "", `result is not a number: "inf - inf" at column 1`},
+		{"200 + 10% - 5% * 2 / 50%", "219.8", ""},
+		{"x = 80; 50% of x + 20 as % of x", "75%", ""},
+		{"20 as % of 0", "", `division by zero: "20 as % of 0" at column 1`},
+		{"x = 2; x >= 2 && !(x == 3) || 1/0 > 1", "true", ""},
+		{"f(n) = n <= 1 ? 1 : n * f(n - 1); f(5) == 120", "true", ""},
+		{"false && 1/0 > 1", "false", ""},
+		{"1 < 2 ? 1 + true : 0", "", `cannot add number and bool: "+" at column 11`},
+		{"true || 1", "true", ""},
+		{"x = 1; x && true", "", `cannot apply && to number: "&&" in statement 2 at column 10`},
+		{"if(false, 1, !x)", "", "undefined name: x at column 15"},
+		{`s = "ab"; t = s + "c"; t > s && t != "abc" ? t : s + 1`, "", `cannot add string and number: "+" in statement 3 at column 52`},
+		{`f(x) = x + "!"; f("hi") == "hi!"`, "true", ""},
+		{`sqrt("4")`, "", `cannot call sqrt with string: "sqrt(\"4\")" at column 1`},
+		{`-"a"`, "", `cannot negate string: "-\"a\"" at column 1`},
+		{"xs = [1, 2, 3]; xs[-1] + sum(xs)", "9", ""},
+		{"avg(1..4) * median([3, 1, 2]) + count([])", "5", ""},
+		{"[1, [2, true]][1] == [2, true]", "true", ""},
+		{"[1, 2][5]", "", `index must be an integer within the list: "[1, 2][5]" at column 1`},
+		{"sqrt([1])", "", `cannot call sqrt with list: "sqrt([1])" at column 1`},
+		{"a = [[2, 1], [1, 3]]; inv(a) * a .* 2 + transpose(a) / det(a)", "[[2.4, 0.1999999999999998], [0.2, 2.6000000000000005]]", ""},
+		{"solve([[2, 1], [1, 3]], [3, 5]) * [1, 1]", "2.2", ""},
+		{"[[1, 2]] * [[1, 2]]", "", `incompatible matrix sizes for *: 1x2 and 1x2: "[[1, 2]] * [[1, 2]]" at column 1`},
+		{"det([1])", "1", ""},
+		{"n = 5; n! / nCr(n, 2) + nPr(n, 2) - 3!^2", "-4", ""},
+		{"percentile(1..9, 75) * normcdf(0) + binopdf(1, 2, 0.5)", "4", ""},
+		{`"a"!`, "", `cannot call factorial with string: "\"a\"!" at column 1`},
+		{"171!", "", `number is too large to represent: "171!" at column 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			program, err := Compile(tt.expression, WithMaxCallDepth(16))
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			result, err := program.Eval(nil)
+			if tt.wantErr != "" {
+				var evalErr *EvalError
+				if !errors.As(err, &evalErr) || err.Error() != tt.wantErr {
+					t.Errorf("\nGot:\t%v\nWant:\t%v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+			if result.String() != tt.wantResult {
+				t.Errorf("\nGot:\t%s\nWant:\t%s", result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestProgram_Eval_allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector makes sync.Pool allocate")
+	}
+
+	env := NewEnv(map[string]float64{"rate": 12.5, "hours": 45, "fee": 20, "age": 30, "income": 45000})
+	env.SetBool("member", false)
+
+	expressions := []string{
+		"rate * hours + fee - max(0, hours - 40) * rate / 2 + -pi",
+		"age >= 18 && income > 30000 || member ? max(0, income - 30000) : 0",
+	}
+
+	for _, expr := range expressions {
+		t.Run(expr, func(t *testing.T) {
+			program, err := Compile(expr)
+			if err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			// warm up the pool of machines
+			if _, err := program.Eval(env); err != nil {
+				t.Fatalf("\nUnexpected error:\t%v", err)
+			}
+
+			allocs := testing.AllocsPerRun(100, func() {
+				_, _ = program.Eval(env)
+			})
+			if allocs != 0 {
+				t.Errorf("\nGot:\t%v allocations\nWant:\t0 allocations", allocs)
+			}
+		})
+	}
+}